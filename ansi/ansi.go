@@ -4,94 +4,97 @@ import (
 	"regexp"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 )
 
-func expand(text string) [][]string {
-	r := regexp.MustCompile(`(?s)((?:\x1b\[.*?m)*)(.)(?:\x1b\[0m)?`)
-	return r.FindAllStringSubmatch(text, -1)
-}
-
-func collapse(expanded [][]string) string {
-	output := ""
-	for _, match := range expanded {
-		output += match[0]
+func Apply(text string, style string) string {
+	var builder strings.Builder
+	for _, c := range parse(text) {
+		if c.text == "\n" {
+			builder.WriteString("\n")
+			continue
+		}
+		builder.WriteString("\x1b[" + style + "m")
+		builder.WriteString(c.prefix)
+		builder.WriteString(c.text)
+		builder.WriteString(reset)
+		builder.WriteString(c.close)
 	}
-	return output
+	return builder.String()
 }
 
-func Apply(text string, style string) string {
-	expanded := expand(text)
-	result := ""
-	for _, match := range expanded {
-		prefix := match[1]
-		letter := match[2]
-
-		if letter == "\n" {
-			result += "\n"
+/*
+Makes `text` a hyperlink to `url` in terminals that support OSC 8. Like
+styles, the hyperlink is applied to each character, so the text can still
+be wrapped and cut anywhere.
+*/
+func Hyperlink(text string, url string) string {
+	url = strings.Map(func(input rune) rune {
+		if unicode.IsControl(input) {
+			return -1
+		}
+		return input
+	}, url)
+	if url == "" {
+		return text
+	}
+	open := "\x1b]8;;" + url + "\x1b\\"
+	var builder strings.Builder
+	for _, c := range parse(text) {
+		/* Newlines are never styled and links can't be nested */
+		if c.text == "\n" || c.close != "" {
+			builder.WriteString(c.raw)
 			continue
 		}
-
-		result += "\x1b[" + style + "m" + prefix + letter + "\x1b[0m"
+		builder.WriteString(open)
+		builder.WriteString(c.raw)
+		builder.WriteString(hyperlinkClose)
 	}
-	return result
+	return builder.String()
 }
 
 func Indent(text string, prefix string, includeFirst bool) string {
-	expanded := expand(text)
-	result := ""
-
+	var builder strings.Builder
 	if includeFirst {
-		result = prefix
+		builder.WriteString(prefix)
 	}
 
-	for _, match := range expanded {
-		full := match[0]
-		letter := match[2]
-
-		if letter == "\n" {
-			result += "\n" + prefix
+	for _, c := range parse(text) {
+		if c.text == "\n" {
+			builder.WriteString("\n" + prefix)
 			continue
 		}
-
-		result += full
+		builder.WriteString(c.raw)
 	}
-	return result
+	return builder.String()
 }
 
 const suffix = " "
 
 func Pad(text string, length int) string {
-	expanded := expand(text)
-	result := ""
+	var builder strings.Builder
 	lineLength := 0
 
-	for _, match := range expanded {
-		full := match[0]
-		letter := match[2]
-
-		if letter == "\n" {
-			amount := length - lineLength
-			if amount <= 0 {
-				result += "\n"
-				lineLength = 0
-				continue
+	for _, c := range parse(text) {
+		if c.text == "\n" {
+			if amount := length - lineLength; amount > 0 {
+				builder.WriteString(strings.Repeat(suffix, amount))
 			}
-			result += strings.Repeat(suffix, amount) + "\n"
+			builder.WriteString("\n")
 			lineLength = 0
 			continue
 		}
 
-		lineLength += 1
-		result += full
+		lineLength += c.width
+		builder.WriteString(c.raw)
 	}
 
 	/* Final line */
-	amount := length - lineLength
-	if amount > 0 {
-		result += strings.Repeat(suffix, amount)
+	if amount := length - lineLength; amount > 0 {
+		builder.WriteString(strings.Repeat(suffix, amount))
 	}
 
-	return result
+	return builder.String()
 }
 
 /*
@@ -99,120 +102,120 @@ I am not convinced this works perfectly, but it is well-tested,
 so I will call it good for now.
 */
 func Wrap(text string, length int) string {
-	expanded := expand(text)
+	cells := parse(text)
 	result := []string{}
-	var line, space, word string
-	var lineLength, spaceLength, wordLength int
+	var line strings.Builder
+	lineLength := 0
 
-	for _, match := range expanded {
-		full := match[0]
-		letter := match[2]
+	/* The pending space and word are the runs of cells from spaceStart to wordStart and from wordStart to the current cell */
+	spaceStart, wordStart := 0, 0
+	spaceLength, wordLength := 0, 0
 
-		if !unicode.IsSpace([]rune(letter)[0]) {
-			if wordLength == length {
+	for i, c := range cells {
+		if !isSpace(c) {
+			if wordLength != 0 && wordLength+c.width > length {
 				/*
 					Word fills an entire line; push it as a line
 					(we know this won't clobber stuff in `line`, because the word has
 					already necessarily forced line to be pushed)
 				*/
-				result = append(result, word)
-				line = ""
+				result = append(result, isolate(render(cells[wordStart:i])))
+				line.Reset()
 				lineLength = 0
-				space = ""
-				spaceLength = 0
-				word = ""
-				wordLength = 0
+				spaceStart, spaceLength = i, 0
+				wordStart, wordLength = i, 0
 			}
 
-			if lineLength+spaceLength+wordLength >= length {
+			if lineLength+spaceLength+wordLength+c.width > length && lineLength+spaceLength != 0 {
 				/* The word no longer fits on the current line; push the current line */
-				result = append(result, line)
-				line = ""
+				result = append(result, isolate(line.String()))
+				line.Reset()
 				lineLength = 0
-				space = ""
-				spaceLength = 0
+				spaceStart, spaceLength = wordStart, 0
 			}
 
-			word += full
-			wordLength += 1
+			wordLength += c.width
 			continue
 		}
 
 		/* This means whitespace has been encountered; if there's a word, add it to the line */
-		if wordLength > 0 {
-			line += space + word
+		if wordStart != i {
+			line.WriteString(render(cells[spaceStart:i]))
 			lineLength += spaceLength + wordLength
-			space = ""
-			spaceLength = 0
-			word = ""
-			wordLength = 0
+			spaceStart, spaceLength = i, 0
+			wordStart, wordLength = i, 0
 		}
 
-		if letter == "\n" {
+		if c.text == "\n" {
 			/*
 				If the spaces can be jammed into the line, add them.
 				This ensures that Wrap(Pad(*)) doesn't eliminate the
 				padding.
 			*/
 			if lineLength+spaceLength <= length {
-				line += space
+				line.WriteString(render(cells[spaceStart:wordStart]))
 				lineLength += spaceLength
 			}
 
 			/* Add the current line as-is and clear everything */
-			result = append(result, line)
-			line = ""
+			result = append(result, isolate(line.String()))
+			line.Reset()
 			lineLength = 0
-			space = ""
-			spaceLength = 0
-			word = ""
-			wordLength = 0
+			spaceStart, spaceLength = i+1, 0
+			wordStart, wordLength = i+1, 0
 		} else {
-			space += full
-			spaceLength += 1
+			spaceLength += c.width
+			wordStart = i + 1
 		}
 	}
 
 	/* Cleanup */
-	if wordLength > 0 {
-		line += space + word
+	if wordStart != len(cells) {
+		line.WriteString(render(cells[spaceStart:]))
 		lineLength += spaceLength + wordLength
 	}
-	finalLetter := ""
-	if len(expanded) > 0 {
-		finalLetter = expanded[len(expanded)-1][2]
+	endsInNewline := false
+	for i := len(cells) - 1; i >= 0; i-- {
+		if cells[i].text != "" {
+			endsInNewline = cells[i].text == "\n"
+			break
+		}
 	}
-	if lineLength > 0 || finalLetter == "\n" {
-		result = append(result, line)
+	if lineLength > 0 || line.Len() > 0 || endsInNewline {
+		result = append(result, isolate(line.String()))
 	}
 
 	return strings.Join(result, "\n")
 }
 
+func isSpace(c cell) bool {
+	if c.text == "" {
+		return false
+	}
+	r, _ := utf8.DecodeRuneInString(c.text)
+	return unicode.IsSpace(r)
+}
+
 func DumbWrap(text string, width int) string {
-	expanded := expand(text)
-	result := ""
+	var builder strings.Builder
 	currentLineLength := 0
 
-	for _, match := range expanded {
-		full := match[0]
-		letter := match[2]
-
-		if letter == "\n" {
+	for _, c := range parse(text) {
+		if c.text == "\n" {
 			currentLineLength = 0
-			result += "\n"
+			builder.WriteString("\n")
 			continue
 		}
 
-		if currentLineLength == width {
+		if currentLineLength != 0 && currentLineLength+c.width > width {
 			currentLineLength = 0
-			result += "\n"
+			builder.WriteString("\n")
 		}
 
-		result += full
-		currentLineLength += 1
+		builder.WriteString(c.raw)
+		currentLineLength += c.width
 	}
-	return result
+	return builder.String()
 }
 
 /*
@@ -220,11 +223,8 @@ Limits `text` to the given `height` and `width`, adding an
 ellipsis to the end and omitting trailing whitespace-only lines
 */
 func Snip(text string, width, height int, ellipsis string) string {
-	snipped := make([]string, 0, height)
-
-	/* This split is fine because newlines are
-	   guaranteed to not be wrapped in ansi codes */
-	lines := strings.Split(text, "\n")
+	/* Only the lines that are kept are parsed */
+	lines := strings.SplitN(text, "\n", height+1)
 
 	requiresEllipsis := false
 
@@ -235,24 +235,29 @@ func Snip(text string, width, height int, ellipsis string) string {
 	}
 
 	/* Adding from back to front */
+	snipped := make([]string, height)
+	first := height
 	for i := height - 1; i >= 0; i -= 1 {
-		line := expand(lines[i])
-		if len(snipped) == 0 {
+		line := parse(lines[i])
+		if first == height {
 			if lineIsOnlyWhitespace(line) {
 				requiresEllipsis = true
 				continue
 			}
 
-			/* Remove last character to make way for ellipsis */
-			if len(line) == width && requiresEllipsis {
-				line = line[:len(line)-1]
+			/* Remove the last characters to make way for the ellipsis */
+			if requiresEllipsis && widthOf(line) >= width {
+				for len(line) != 0 && widthOf(line) > width-1 {
+					line = line[:len(line)-1]
+				}
 			}
 		}
 
-		snipped = append([]string{collapse(line)}, snipped...)
+		first -= 1
+		snipped[first] = render(line)
 	}
 
-	output := strings.Join(snipped, "\n")
+	output := strings.Join(snipped[first:], "\n")
 
 	if requiresEllipsis {
 		output += ellipsis
@@ -261,9 +266,9 @@ func Snip(text string, width, height int, ellipsis string) string {
 	return output
 }
 
-func lineIsOnlyWhitespace(expanded [][]string) bool {
-	for _, match := range expanded {
-		if !unicode.IsSpace([]rune(match[2])[0]) {
+func lineIsOnlyWhitespace(cells []cell) bool {
+	for _, c := range cells {
+		if c.text != "" && !isSpace(c) {
 			return false
 		}
 	}
@@ -275,6 +280,17 @@ func Height(text string) uint {
 	return uint(strings.Count(text, "\n")) + 1
 }
 
+/* Returns the length of the longest line */
+func Width(text string) int {
+	longest := 0
+	for _, line := range lines(parse(text)) {
+		if length := widthOf(line); length > longest {
+			longest = length
+		}
+	}
+	return longest
+}
+
 func CenterVertically(prefix, centered, suffix string, height uint) string {
 	prefixHeight, centeredHeight, suffixHeight := Height(prefix), Height(centered), Height(suffix)
 	if height <= centeredHeight {
@@ -313,24 +329,38 @@ func ReplaceLastLine(original, replacement string) string {
 
 func SetLength(text string, length int, ellipsis string) string {
 	text = Squash(Scrub(text))
-	runes := []rune(text)
 	if length == 0 {
 		return ""
 	}
-	if len(runes) > length {
-		return string(runes[:length-1]) + ellipsis
+	cells := parse(text)
+	if width := widthOf(cells); width <= length {
+		return text + strings.Repeat(" ", length-width)
 	}
-	if len(runes) < length {
-		return string(runes) + strings.Repeat(" ", length-len(runes))
+	/* Leave room for the ellipsis, padding if a wide character doesn't fit beside it */
+	var builder strings.Builder
+	width := 0
+	for _, c := range cells {
+		if width+c.width > length-1 {
+			break
+		}
+		builder.WriteString(c.raw)
+		width += c.width
 	}
-	return text
+	return builder.String() + strings.Repeat(" ", length-1-width) + ellipsis
 }
 
 func Squash(text string) string {
 	return strings.ReplaceAll(text, "\n", " ")
 }
 
+var hyperlinkSequence = regexp.MustCompile(`\x1b\]8;[^\x1b]*\x1b\\`)
+
+var styleSequence = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+/* Removes styles, hyperlinks and other control characters */
 func Scrub(text string) string {
+	text = hyperlinkSequence.ReplaceAllString(text, "")
+	text = styleSequence.ReplaceAllString(text, "")
 	text = strings.ReplaceAll(text, "\t", "    ")
 	text = strings.Map(func(input rune) rune {
 		if input != '\n' && unicode.IsControl(input) {
@@ -296,3 +296,202 @@ func TestCenterVertically(t *testing.T) {
 		}
 	}
 }
+
+func TestWidth(t *testing.T) {
+	tests := []struct {
+		input  string
+		output int
+	}{
+		{"", 0},
+		{"abc", 3},
+		{"ab\nabcd\na", 4},
+		{Apply("styled", "1") + " text", 11},
+	}
+
+	for _, test := range tests {
+		if width := Width(test.input); width != test.output {
+			t.Fatalf("expected width of %q to be %d not %d", test.input, test.output, width)
+		}
+	}
+}
+
+func TestHyperlink(t *testing.T) {
+	link := func(text string) string {
+		return "\x1b]8;;https://example.org\x1b\\" + text + "\x1b]8;;\x1b\\"
+	}
+	linked := Hyperlink("ab cd", "https://example.org")
+
+	if width := Width(linked); width != 5 {
+		t.Fatalf("expected a hyperlink to take up no space, but its width is %d", width)
+	}
+
+	wrapped := Wrap(linked, 3)
+	expected := link("a") + link("b") + "\n" + link("c") + link("d")
+	if wrapped != expected {
+		t.Fatalf("expected %q but got %q", expected, wrapped)
+	}
+
+	styled := Apply(Hyperlink("a", "https://example.org"), "1")
+	expected = "\x1b[1m\x1b]8;;https://example.org\x1b\\a\x1b[0m\x1b]8;;\x1b\\"
+	if styled != expected {
+		t.Fatalf("expected %q but got %q", expected, styled)
+	}
+
+	if scrubbed := Scrub(linked); scrubbed != "ab cd" {
+		t.Fatalf("expected scrubbing to remove the hyperlink, not leave %q", scrubbed)
+	}
+
+	if scrubbed := Scrub(styled); scrubbed != "a" {
+		t.Fatalf("expected scrubbing to remove the style and hyperlink, not leave %q", scrubbed)
+	}
+
+	if snipped := Snip(Hyperlink("ab\ncd", "https://example.org"), 2, 1, "…"); snipped != link("a")+"…" {
+		t.Fatalf("expected snipping to keep whole hyperlinks, not %q", snipped)
+	}
+}
+
+func TestWideCharacters(t *testing.T) {
+	tests := []struct {
+		input  string
+		output int
+	}{
+		{"日本語", 6},
+		{"한국어 text", 11},
+		/* A family joined with zero width joiners */
+		{"\U0001F468\u200D\U0001F469\u200D\U0001F467", 2},
+		/* A flag, made of two regional indicators */
+		{"\U0001F1EF\U0001F1F5", 2},
+		/* e followed by a combining acute accent */
+		{"e\u0301", 1},
+		/* A heart given emoji presentation */
+		{"\u2764\uFE0F", 2},
+	}
+
+	for _, test := range tests {
+		if width := Width(test.input); width != test.output {
+			t.Fatalf("expected width of %q to be %d not %d", test.input, test.output, width)
+		}
+	}
+}
+
+func TestWrapWideCharacters(t *testing.T) {
+	/* A wide character that doesn't fit in the last column moves to the next line */
+	if wrapped := Wrap("日本語", 5); wrapped != "日本\n語" {
+		t.Fatalf("expected %q but got %q", "日本\n語", wrapped)
+	}
+
+	/* Clusters are never split */
+	family := "\U0001F468\u200D\U0001F469\u200D\U0001F467"
+	if wrapped := Wrap(family+family, 2); wrapped != family+"\n"+family {
+		t.Fatalf("expected %q but got %q", family+"\n"+family, wrapped)
+	}
+	accented := "e\u0301"
+	if wrapped := DumbWrap(accented+accented, 1); wrapped != accented+"\n"+accented {
+		t.Fatalf("expected %q but got %q", accented+"\n"+accented, wrapped)
+	}
+}
+
+func TestPadWideCharacters(t *testing.T) {
+	if padded := Pad("日本\nab", 5); padded != "日本 \nab   " {
+		t.Fatalf("expected %q but got %q", "日本 \nab   ", padded)
+	}
+}
+
+func TestSetLengthWideCharacters(t *testing.T) {
+	tests := []struct {
+		input  string
+		length int
+		output string
+	}{
+		{"日本語", 6, "日本語"},
+		{"日本語", 5, "日本…"},
+		{"日本語", 4, "日 …"},
+		{"e\u0301e\u0301e\u0301", 2, "e\u0301…"},
+	}
+
+	for _, test := range tests {
+		if output := SetLength(test.input, test.length, "…"); output != test.output {
+			t.Fatalf("expected %q at length %d to be %q not %q", test.input, test.length, test.output, output)
+		}
+	}
+}
+
+func TestSnipWideCharacters(t *testing.T) {
+	if snipped := Snip("日本\n語", 4, 1, "…"); snipped != "日…" {
+		t.Fatalf("expected %q but got %q", "日…", snipped)
+	}
+}
+
+func TestRightToLeftIsolation(t *testing.T) {
+	wrapped := Wrap("שלום world\nhello", 20)
+	expected := "\u2068שלום world\u2069\nhello"
+	if wrapped != expected {
+		t.Fatalf("expected %q but got %q", expected, wrapped)
+	}
+	if Width(wrapped) != 10 {
+		t.Fatalf("expected the isolates to take up no space, but the width is %d", Width(wrapped))
+	}
+	if rewrapped := Wrap(wrapped, 20); rewrapped != expected {
+		t.Fatalf("expected wrapping twice to isolate once, got %q", rewrapped)
+	}
+}
+
+/* A long article's worth of styled text, as rendered posts are */
+func benchmarkText() string {
+	paragraph := "Lorem ipsum " + Apply("dolor sit amet", "1") + ", consectetur adipiscing elit, sed do " +
+		Hyperlink(Apply("eiusmod tempor", "4"), "https://example.org") + " incididunt ut labore et dolore magna aliqua. " +
+		"日本語のテキストも少し。\n\n"
+	text := ""
+	for i := 0; i < 100; i++ {
+		text += paragraph
+	}
+	return text
+}
+
+func BenchmarkWrap(b *testing.B) {
+	text := benchmarkText()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Wrap(text, 80)
+	}
+}
+
+func BenchmarkApply(b *testing.B) {
+	text := benchmarkText()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Apply(text, "48;2;75;75;75")
+	}
+}
+
+func BenchmarkIndent(b *testing.B) {
+	text := Wrap(benchmarkText(), 80)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Indent(text, "  ", true)
+	}
+}
+
+func BenchmarkPad(b *testing.B) {
+	text := Wrap(benchmarkText(), 80)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Pad(text, 80)
+	}
+}
+
+func BenchmarkSnip(b *testing.B) {
+	text := Wrap(benchmarkText(), 80)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Snip(text, 80, 20, "…")
+	}
+}
+
+func BenchmarkWidth(b *testing.B) {
+	text := Wrap(benchmarkText(), 80)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Width(text)
+	}
+}
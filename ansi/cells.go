@@ -0,0 +1,137 @@
+package ansi
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+/*
+	Styled text is parsed once into cells, each a grapheme cluster with
+	the escape sequences that style it: SGR codes and the opening of an
+	OSC 8 hyperlink before it, and a reset and the closing of the
+	hyperlink after it. The sequences take up no space, so they always
+	travel with their cluster, and the functions in this package lay
+	out cells rather than searching strings.
+*/
+
+type cell struct {
+	/* Exactly as it appeared in the text */
+	raw string
+
+	prefix string
+	text   string
+	close  string
+	width  int
+}
+
+const (
+	reset          = "\x1b[0m"
+	hyperlinkClose = "\x1b]8;;\x1b\\"
+)
+
+func parse(text string) []cell {
+	cells := make([]cell, 0, len(text)/2)
+	for position := 0; position < len(text); {
+		start := position
+		for {
+			if length := sequenceLength(text[position:]); length != 0 {
+				position += length
+				continue
+			}
+			break
+		}
+		prefixEnd := position
+
+		if position == len(text) {
+			/* Sequences with nothing after them stay with the last cell */
+			if len(cells) != 0 {
+				cells[len(cells)-1].raw += text[start:]
+			} else {
+				cells = append(cells, cell{raw: text[start:]})
+			}
+			break
+		}
+
+		_, size := utf8.DecodeRuneInString(text[position:])
+		letter := text[position : position+size]
+		position += size
+		if strings.HasPrefix(text[position:], reset) {
+			position += len(reset)
+		}
+		close := ""
+		if strings.HasPrefix(text[position:], hyperlinkClose) {
+			close = hyperlinkClose
+			position += len(hyperlinkClose)
+		}
+
+		if len(cells) != 0 && cells[len(cells)-1].text != "" && extends(cells[len(cells)-1].text, letter) {
+			last := &cells[len(cells)-1]
+			last.raw += text[start:position]
+			last.text += letter
+			last.close = close
+			last.width = cellWidth(last.text)
+			continue
+		}
+		cells = append(cells, cell{
+			raw:    text[start:position],
+			prefix: text[start:prefixEnd],
+			text:   letter,
+			close:  close,
+			width:  cellWidth(letter),
+		})
+	}
+	return cells
+}
+
+/* The length of the SGR code or OSC 8 hyperlink opening at the start of `text`, if there is one */
+func sequenceLength(text string) int {
+	if strings.HasPrefix(text, "\x1b[") {
+		if end := strings.IndexByte(text[2:], 'm'); end != -1 {
+			return end + 3
+		}
+		return 0
+	}
+	if strings.HasPrefix(text, "\x1b]8;") {
+		/* The parameters, which can't contain semicolons, then a URL, which can't be empty */
+		parameters := strings.IndexByte(text[4:], ';')
+		if parameters == -1 || strings.IndexByte(text[4:4+parameters], '\x1b') != -1 {
+			return 0
+		}
+		url := 4 + parameters + 1
+		end := strings.Index(text[url:], "\x1b\\")
+		if end <= 0 || strings.IndexByte(text[url:url+end], '\x1b') != -1 {
+			return 0
+		}
+		return url + end + 2
+	}
+	return 0
+}
+
+func render(cells []cell) string {
+	var builder strings.Builder
+	for _, c := range cells {
+		builder.WriteString(c.raw)
+	}
+	return builder.String()
+}
+
+/* Splits parsed text at its newlines, which are never styled */
+func lines(cells []cell) [][]cell {
+	result := [][]cell{}
+	start := 0
+	for i, c := range cells {
+		if c.text == "\n" {
+			result = append(result, cells[start:i])
+			start = i + 1
+		}
+	}
+	return append(result, cells[start:])
+}
+
+func widthOf(cells []cell) int {
+	width := 0
+	for _, c := range cells {
+		width += c.width
+	}
+	return width
+}
@@ -0,0 +1,149 @@
+package ansi
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+/*
+	Terminals lay text out in cells. Most characters take up one,
+	East Asian wide characters and emoji take up two, and combining
+	marks take up none, instead joining the character before them.
+	A character together with everything that joins it is a grapheme
+	cluster, which is never split. This follows UAX #29 and #11 closely
+	enough for the text found in posts, without their full tables.
+*/
+
+const (
+	zeroWidthJoiner       = '\u200D'
+	emojiPresentation     = '\uFE0F'
+	firstStrongIsolate    = '\u2068'
+	popDirectionalIsolate = '\u2069'
+)
+
+/* Whether `next` belongs to the same grapheme cluster as `cluster` */
+func extends(cluster string, next string) bool {
+	if cluster == "\n" || next == "\n" {
+		return false
+	}
+	r, _ := utf8.DecodeRuneInString(next)
+	last, _ := utf8.DecodeLastRuneInString(cluster)
+	switch {
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc):
+		return true
+	case r == zeroWidthJoiner:
+		return true
+	case r >= 0xFE00 && r <= 0xFE0F, r >= 0xE0100 && r <= 0xE01EF:
+		/* Variation selectors */
+		return true
+	case r >= 0x1F3FB && r <= 0x1F3FF:
+		/* Skin tone modifiers */
+		return true
+	case r >= 0xE0020 && r <= 0xE007F:
+		/* Tags, as in subdivision flags */
+		return true
+	case last == zeroWidthJoiner:
+		/* Emoji joined into one, like families */
+		return true
+	case isRegionalIndicator(r):
+		/* Flags are pairs of regional indicators */
+		count := 0
+		for _, previous := range cluster {
+			if isRegionalIndicator(previous) {
+				count += 1
+			}
+		}
+		return count%2 == 1
+	}
+	return false
+}
+
+/* The number of cells a grapheme cluster takes up */
+func cellWidth(cluster string) int {
+	r, _ := utf8.DecodeRuneInString(cluster)
+	switch {
+	case r == '\t':
+		return 1
+	case r < 0x20 || (r >= 0x7F && r < 0xA0):
+		return 0
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		return 0
+	case isWide(r), isRegionalIndicator(r):
+		return 2
+	case strings.ContainsRune(cluster, emojiPresentation):
+		return 2
+	}
+	return 1
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1F1E6 && r <= 0x1F1FF
+}
+
+/* The wide and fullwidth ranges of Unicode's East Asian Width property */
+var wideRanges = [][2]rune{
+	{0x1100, 0x115F}, {0x231A, 0x231B}, {0x2329, 0x232A}, {0x23E9, 0x23EC}, {0x23F0, 0x23F0},
+	{0x23F3, 0x23F3}, {0x25FD, 0x25FE}, {0x2614, 0x2615}, {0x2648, 0x2653}, {0x267F, 0x267F},
+	{0x2693, 0x2693}, {0x26A1, 0x26A1}, {0x26AA, 0x26AB}, {0x26BD, 0x26BE}, {0x26C4, 0x26C5},
+	{0x26CE, 0x26CE}, {0x26D4, 0x26D4}, {0x26EA, 0x26EA}, {0x26F2, 0x26F3}, {0x26F5, 0x26F5},
+	{0x26FA, 0x26FA}, {0x26FD, 0x26FD}, {0x2705, 0x2705}, {0x270A, 0x270B}, {0x2728, 0x2728},
+	{0x274C, 0x274C}, {0x274E, 0x274E}, {0x2753, 0x2755}, {0x2757, 0x2757}, {0x2795, 0x2797},
+	{0x27B0, 0x27B0}, {0x27BF, 0x27BF}, {0x2B1B, 0x2B1C}, {0x2B50, 0x2B50}, {0x2B55, 0x2B55},
+	{0x2E80, 0x303E}, {0x3041, 0x33FF}, {0x3400, 0x4DBF}, {0x4E00, 0x9FFF}, {0xA000, 0xA4CF},
+	{0xA960, 0xA97F}, {0xAC00, 0xD7A3}, {0xF900, 0xFAFF}, {0xFE10, 0xFE19}, {0xFE30, 0xFE6F},
+	{0xFF00, 0xFF60}, {0xFFE0, 0xFFE6}, {0x16FE0, 0x16FE4}, {0x17000, 0x18CFF}, {0x1B000, 0x1B2FF},
+	{0x1F004, 0x1F004}, {0x1F0CF, 0x1F0CF}, {0x1F18E, 0x1F18E}, {0x1F191, 0x1F19A}, {0x1F200, 0x1F202},
+	{0x1F210, 0x1F23B}, {0x1F240, 0x1F248}, {0x1F250, 0x1F251}, {0x1F260, 0x1F265}, {0x1F300, 0x1F320},
+	{0x1F32D, 0x1F335}, {0x1F337, 0x1F37C}, {0x1F37E, 0x1F393}, {0x1F3A0, 0x1F3CA}, {0x1F3CF, 0x1F3D3},
+	{0x1F3E0, 0x1F3F0}, {0x1F3F4, 0x1F3F4}, {0x1F3F8, 0x1F43E}, {0x1F440, 0x1F440}, {0x1F442, 0x1F4FC},
+	{0x1F4FF, 0x1F53D}, {0x1F54B, 0x1F54E}, {0x1F550, 0x1F567}, {0x1F57A, 0x1F57A}, {0x1F595, 0x1F596},
+	{0x1F5A4, 0x1F5A4}, {0x1F5FB, 0x1F64F}, {0x1F680, 0x1F6C5}, {0x1F6CC, 0x1F6CC}, {0x1F6D0, 0x1F6D2},
+	{0x1F6D5, 0x1F6D7}, {0x1F6DC, 0x1F6DF}, {0x1F6EB, 0x1F6EC}, {0x1F6F4, 0x1F6FC}, {0x1F7E0, 0x1F7EB},
+	{0x1F7F0, 0x1F7F0}, {0x1F90C, 0x1F93A}, {0x1F93C, 0x1F945}, {0x1F947, 0x1F9FF}, {0x1FA70, 0x1FAFF},
+	{0x20000, 0x2FFFD}, {0x30000, 0x3FFFD},
+}
+
+func isWide(r rune) bool {
+	if r < wideRanges[0][0] {
+		return false
+	}
+	/* Binary search, since this is checked for every character */
+	low, high := 0, len(wideRanges)-1
+	for low <= high {
+		middle := (low + high) / 2
+		switch {
+		case r < wideRanges[middle][0]:
+			high = middle - 1
+		case r > wideRanges[middle][1]:
+			low = middle + 1
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+/* Hebrew, Arabic and the other scripts written right to left */
+func isRightToLeft(r rune) bool {
+	return (r >= 0x0590 && r <= 0x08FF) || (r >= 0xFB1D && r <= 0xFDFF) ||
+		(r >= 0xFE70 && r <= 0xFEFF) || (r >= 0x10800 && r <= 0x10FFF) || (r >= 0x1E800 && r <= 0x1EFFF)
+}
+
+/*
+Wraps a line containing right-to-left text in a first strong isolate, so
+that terminals which reorder bidirectional text keep it to the line's
+content, leaving the borders and indentation around it in place
+*/
+func isolate(line string) string {
+	line = strings.Map(func(input rune) rune {
+		if input == firstStrongIsolate || input == popDirectionalIsolate {
+			return -1
+		}
+		return input
+	}, line)
+	if strings.IndexFunc(line, isRightToLeft) == -1 {
+		return line
+	}
+	return string(firstStrongIsolate) + line + string(popDirectionalIsolate)
+}
@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	lru "github.com/hashicorp/golang-lru/v2"
 	"golang.org/x/sync/singleflight"
+	"servitor/config"
 	"servitor/jtp"
 	"servitor/object"
 	"net/url"
@@ -16,6 +18,7 @@ const MAX_REDIRECTS = 20
 
 func FetchUnknown(input any, source *url.URL) (object.Object, *url.URL, error) {
 	var obj object.Object
+	var requested *url.URL
 	switch narrowed := input.(type) {
 	case string:
 		ref, err := url.Parse(narrowed)
@@ -23,10 +26,11 @@ func FetchUnknown(input any, source *url.URL) (object.Object, *url.URL, error) {
 			return nil, nil, err
 		}
 		if source != nil {
-			obj, source, err = FetchURL(source.ResolveReference(ref))
+			requested = source.ResolveReference(ref)
 		} else {
-			obj, source, err = FetchURL(ref)
+			requested = ref
 		}
+		obj, source, err = FetchURL(requested)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -44,6 +48,7 @@ func FetchUnknown(input any, source *url.URL) (object.Object, *url.URL, error) {
 	}
 	/* Refetch if necessary */
 	if id != nil && (source == nil || source.Host != id.Host || len(obj) <= 2) {
+		requested = id
 		obj, source, err = FetchURL(id)
 		if err != nil {
 			return nil, nil, err
@@ -60,9 +65,46 @@ func FetchUnknown(input any, source *url.URL) (object.Object, *url.URL, error) {
 		}
 	}
 
+	if id != nil && requested != nil {
+		origins.Add(id.String(), Origin{Requested: requested, Received: source})
+	}
+
 	return obj, id, nil
 }
 
+/* Removes whatever FetchUnknown would have found in the cache for the input, so it is fetched again */
+func Evict(input any, source *url.URL) {
+	switch narrowed := input.(type) {
+	case string:
+		ref, err := url.Parse(narrowed)
+		if err != nil {
+			return
+		}
+		if source != nil {
+			ref = source.ResolveReference(ref)
+		}
+		jtp.Evict(ref)
+	case map[string]any:
+		if id, err := object.Object(narrowed).GetURL("id"); err == nil {
+			jtp.Evict(id)
+		}
+	}
+}
+
+/* Where a fetched object came from, kept so it can be shown when inspecting the object */
+type Origin struct {
+	Requested *url.URL
+	/* Differs from Requested if there were redirects */
+	Received *url.URL
+}
+
+var origins, _ = lru.New[string, Origin](config.Parsed.Network.CacheSize)
+
+/* Returns where the object with the given identifier was last fetched from, false if it was only seen embedded in others */
+func OriginOf(id *url.URL) (Origin, bool) {
+	return origins.Get(id.String())
+}
+
 var group singleflight.Group
 
 type bundle struct {
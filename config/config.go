@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"github.com/BurntSushi/toml"
+	"golang.org/x/exp/slices"
 	"os"
 	"strings"
 	"strconv"
@@ -14,20 +15,36 @@ type Config struct {
 	Feeds     map[string][]string    `toml:"feeds"`
 	Media	  struct {
 		Hook []string `toml:"hook"`
+		PreferredMaxHeight uint64 `toml:"preferred_max_height"`
+		PreferredFormats []string `toml:"preferred_formats"`
+		InlineImages bool `toml:"inline_images"`
+		ImageProtocol string `toml:"image_protocol"`
 	}	`toml:"media"`
 	Style	  struct {
+		Hyperlinks bool `toml:"hyperlinks"`
+		PreferSource bool `toml:"prefer_source"`
 		Colors struct {
 			Primary string `toml:"primary"`
 			Error string `toml:"error"`
 			Highlight string `toml:"highlight"`
 			Code string `toml:"code_background"`
 		} `toml:"colors"`
+		Syntax struct {
+			Keyword string `toml:"keyword"`
+			Type string `toml:"type"`
+			String string `toml:"string"`
+			Number string `toml:"number"`
+			Comment string `toml:"comment"`
+		} `toml:"syntax"`
 	} `toml:"style"`
 	Network   struct {
 		Context int `toml:"preload_amount"`
 		Timeout time.Duration `toml:"timeout_seconds"`
 		CacheSize int `toml:"cache_size"`
 	} `toml:"network"`
+	Splicing  struct {
+		Activities []string `toml:"activities"`
+	} `toml:"splicing"`
 }
 
 var Parsed *Config = nil
@@ -55,13 +72,25 @@ func parse(location string) (*Config, error) {
 	config := &Config{}
 	config.Feeds = map[string][]string{}
 	config.Media.Hook = []string{"xdg-open", "%url"}
+	config.Media.PreferredMaxHeight = 0
+	config.Media.PreferredFormats = []string{}
+	config.Media.InlineImages = false
+	config.Media.ImageProtocol = "auto"
+	config.Style.Hyperlinks = false
+	config.Style.PreferSource = false
 	config.Style.Colors.Primary = "#A4f59b"
 	config.Style.Colors.Error = "#9c3535"
 	config.Style.Colors.Highlight = "#0d7d00"
 	config.Style.Colors.Code = "#4b4b4b"
+	config.Style.Syntax.Keyword = "#f28fad"
+	config.Style.Syntax.Type = "#8fc9f2"
+	config.Style.Syntax.String = "#e5d08f"
+	config.Style.Syntax.Number = "#f2b48f"
+	config.Style.Syntax.Comment = "#9a9a9a"
 	config.Network.Context = 5
 	config.Network.Timeout = 10
 	config.Network.CacheSize = 128
+	config.Splicing.Activities = []string{"Create", "Announce", "Like", "Dislike"}
 
 	if location == "" {
 		return config, nil
@@ -82,6 +111,165 @@ func parse(location string) (*Config, error) {
 	return config, nil
 }
 
+/*
+Rewrites every feed entry equal to `old` so that it is `new` instead, both
+in the config file and in Parsed. This is done textually, rather than by
+re-encoding the config, so that the comments and layout of the file survive.
+*/
+func ReplaceFeedEntry(old, new string) error {
+	location := location()
+	if location == "" {
+		return errors.New("failed to locate the config file")
+	}
+	info, err := os.Stat(location)
+	if err != nil {
+		return err
+	}
+	contents, err := os.ReadFile(location)
+	if err != nil {
+		return err
+	}
+
+	text, found := replaceFeedEntry(string(contents), old, new)
+	if !found {
+		return fmt.Errorf("%s is not in the feeds of %s", old, location)
+	}
+
+	if err := os.WriteFile(location, []byte(text), info.Mode()); err != nil {
+		return err
+	}
+
+	for _, entries := range Parsed.Feeds {
+		for i, entry := range entries {
+			if entry == old {
+				entries[i] = new
+			}
+		}
+	}
+	return nil
+}
+
+/*
+Replaces the strings equal to `old` within the arrays of the [feeds]
+table, leaving comments, other tables and other keys alone
+*/
+func replaceFeedEntry(text, old, new string) (string, bool) {
+	var output strings.Builder
+	found := false
+	inFeeds := false
+	depth := 0
+	lineStart := true
+	for i := 0; i < len(text); {
+		switch character := text[i]; {
+		case character == '\n':
+			output.WriteByte(character)
+			lineStart = true
+			i += 1
+			continue
+		case character == ' ' || character == '\t':
+			output.WriteByte(character)
+			i += 1
+			continue
+		case character == '#':
+			end := strings.IndexByte(text[i:], '\n')
+			if end == -1 {
+				end = len(text) - i
+			}
+			output.WriteString(text[i : i+end])
+			i += end
+		case character == '"' || character == '\'':
+			length := stringLength(text[i:])
+			literal := text[i : i+length]
+			if inFeeds && depth > 0 && decodeString(literal) == old {
+				found = true
+				literal = basicString(new)
+			}
+			output.WriteString(literal)
+			i += length
+		case character == '[' && depth == 0 && lineStart:
+			/* A table header, which only [feeds] is of interest */
+			end := strings.IndexByte(text[i:], '\n')
+			if end == -1 {
+				end = len(text) - i
+			}
+			header := text[i : i+end]
+			if comment := strings.IndexByte(header, '#'); comment != -1 {
+				header = header[:comment]
+			}
+			inFeeds = strings.TrimSpace(header) != "" && !strings.HasPrefix(header, "[[") &&
+				strings.TrimSpace(strings.Trim(strings.TrimSpace(header), "[]")) == "feeds"
+			output.WriteString(text[i : i+end])
+			i += end
+		case character == '[':
+			depth += 1
+			output.WriteByte(character)
+			i += 1
+		case character == ']':
+			if depth > 0 {
+				depth -= 1
+			}
+			output.WriteByte(character)
+			i += 1
+		default:
+			output.WriteByte(character)
+			i += 1
+		}
+		lineStart = false
+	}
+	return output.String(), found
+}
+
+/* The length of the TOML string at the start of `text`, which may be multiline, basic or literal */
+func stringLength(text string) int {
+	quote := text[:1]
+	if strings.HasPrefix(text, strings.Repeat(quote, 3)) {
+		quote = strings.Repeat(quote, 3)
+	}
+	for i := len(quote); i < len(text); i++ {
+		if text[i] == '\\' && quote[0] == '"' {
+			i += 1
+			continue
+		}
+		if strings.HasPrefix(text[i:], quote) {
+			return i + len(quote)
+		}
+	}
+	return len(text)
+}
+
+func decodeString(literal string) string {
+	var decoded struct {
+		Value string `toml:"value"`
+	}
+	if _, err := toml.Decode("value = "+literal, &decoded); err != nil {
+		return ""
+	}
+	return decoded.Value
+}
+
+/* Encodes `value` as a TOML basic string */
+func basicString(value string) string {
+	var output strings.Builder
+	output.WriteByte('"')
+	for _, r := range value {
+		switch {
+		case r == '"' || r == '\\':
+			output.WriteByte('\\')
+			output.WriteRune(r)
+		case r == '\n':
+			output.WriteString(`\n`)
+		case r == '\t':
+			output.WriteString(`\t`)
+		case r < 0x20 || r == 0x7F:
+			output.WriteString(fmt.Sprintf(`\u%04X`, r))
+		default:
+			output.WriteRune(r)
+		}
+	}
+	output.WriteByte('"')
+	return output.String()
+}
+
 func location() string {
 	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
 		return xdg + "/servitor/config.toml"
@@ -139,6 +327,41 @@ func postprocess(config *Config) error {
 	if err != nil {
 		return fmt.Errorf("key style.colors.code is invalid: %w", err)
 	}
+	for _, color := range []struct {
+		key   string
+		value *string
+	}{
+		{"keyword", &config.Style.Syntax.Keyword},
+		{"type", &config.Style.Syntax.Type},
+		{"string", &config.Style.Syntax.String},
+		{"number", &config.Style.Syntax.Number},
+		{"comment", &config.Style.Syntax.Comment},
+	} {
+		*color.value, err = hexToAnsi(*color.value)
+		if err != nil {
+			return fmt.Errorf("key style.syntax.%s is invalid: %w", color.key, err)
+		}
+	}
+	switch config.Media.ImageProtocol {
+	case "auto", "kitty", "sixel", "blocks":
+		break
+	default:
+		return errors.New("key media.image_protocol must be one of auto, kitty, sixel, or blocks")
+	}
+	for _, kind := range config.Splicing.Activities {
+		if !slices.Contains(ActivityKinds, kind) {
+			return fmt.Errorf("key splicing.activities contains %s, which must be one of %s", kind, strings.Join(ActivityKinds, ", "))
+		}
+	}
 	config.Network.Timeout *= time.Second
 	return nil
 }
+
+/*
+Every kind of activity that can be displayed, which splicing.activities
+is checked against; pub uses this list too, since config can't import pub
+*/
+var ActivityKinds = []string{
+	"Create", "Announce", "Dislike", "Like",
+	"Update", "Delete", "Follow", "Add", "Remove", "Move", "Undo", "EmojiReact", "Block", "Flag",
+}
@@ -0,0 +1,153 @@
+package diff
+
+import (
+	"strings"
+	"unicode"
+)
+
+/*
+	Compares two texts word by word, as a longest common subsequence.
+	Whitespace is kept so the texts can be put back together, but only
+	whether it breaks a line or a paragraph is significant, since the
+	rest is padding.
+*/
+
+type Kind int
+
+const (
+	Same Kind = iota
+	Removed
+	Added
+)
+
+type Piece struct {
+	Kind Kind
+	Text string
+}
+
+/* Beyond this many cells, the table is too costly and the texts are treated as wholly different */
+const maxCells = 4_000_000
+
+func Words(before, after string) []Piece {
+	old, new := tokenize(before), tokenize(after)
+
+	/* Edits tend to be small, so trimming what is shared keeps the table small */
+	prefix := 0
+	for prefix < len(old) && prefix < len(new) && old[prefix] == new[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(old)-prefix && suffix < len(new)-prefix && old[len(old)-1-suffix] == new[len(new)-1-suffix] {
+		suffix++
+	}
+
+	pieces := []Piece{}
+	for _, token := range old[:prefix] {
+		pieces = appendPiece(pieces, Same, token)
+	}
+	pieces = append(pieces, compare(old[prefix:len(old)-suffix], new[prefix:len(new)-suffix])...)
+	for _, token := range old[len(old)-suffix:] {
+		pieces = appendPiece(pieces, Same, token)
+	}
+	return pieces
+}
+
+func compare(old, new []string) []Piece {
+	pieces := []Piece{}
+	if len(old)*len(new) > maxCells {
+		for _, token := range old {
+			pieces = appendPiece(pieces, Removed, token)
+		}
+		for _, token := range new {
+			pieces = appendPiece(pieces, Added, token)
+		}
+		return pieces
+	}
+
+	/* lengths[i][j] is the length of the common subsequence of old[i:] and new[j:] */
+	lengths := make([][]int32, len(old)+1)
+	for i := range lengths {
+		lengths[i] = make([]int32, len(new)+1)
+	}
+	for i := len(old) - 1; i >= 0; i-- {
+		for j := len(new) - 1; j >= 0; j-- {
+			if old[i] == new[j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else if lengths[i+1][j] >= lengths[i][j+1] {
+				lengths[i][j] = lengths[i+1][j]
+			} else {
+				lengths[i][j] = lengths[i][j+1]
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(old) && j < len(new) {
+		if old[i] == new[j] {
+			pieces = appendPiece(pieces, Same, old[i])
+			i++
+			j++
+		} else if lengths[i+1][j] >= lengths[i][j+1] {
+			pieces = appendPiece(pieces, Removed, old[i])
+			i++
+		} else {
+			pieces = appendPiece(pieces, Added, new[j])
+			j++
+		}
+	}
+	for ; i < len(old); i++ {
+		pieces = appendPiece(pieces, Removed, old[i])
+	}
+	for ; j < len(new); j++ {
+		pieces = appendPiece(pieces, Added, new[j])
+	}
+	return pieces
+}
+
+/* Merges runs of the same kind so they can be styled as one */
+func appendPiece(pieces []Piece, kind Kind, text string) []Piece {
+	if len(pieces) != 0 && pieces[len(pieces)-1].Kind == kind {
+		pieces[len(pieces)-1].Text += text
+		return pieces
+	}
+	return append(pieces, Piece{kind, text})
+}
+
+/* Splits the text into words and the whitespace between them */
+func tokenize(text string) []string {
+	tokens := []string{}
+	var current strings.Builder
+	inSpace := false
+	flush := func() {
+		if current.Len() == 0 {
+			return
+		}
+		token := current.String()
+		if inSpace {
+			token = normalize(token)
+		}
+		tokens = append(tokens, token)
+		current.Reset()
+	}
+	for _, character := range text {
+		space := unicode.IsSpace(character)
+		if space != inSpace {
+			flush()
+			inSpace = space
+		}
+		current.WriteRune(character)
+	}
+	flush()
+	return tokens
+}
+
+func normalize(whitespace string) string {
+	switch strings.Count(whitespace, "\n") {
+	case 0:
+		return " "
+	case 1:
+		return "\n"
+	default:
+		return "\n\n"
+	}
+}
@@ -0,0 +1,50 @@
+package diff
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWords(t *testing.T) {
+	tests := []struct {
+		before, after string
+		expected      []Piece
+	}{
+		{
+			"the quick fox", "the quick fox",
+			[]Piece{{Same, "the quick fox"}},
+		},
+		{
+			"the quick fox", "the slow fox",
+			[]Piece{{Same, "the "}, {Removed, "quick"}, {Added, "slow"}, {Same, " fox"}},
+		},
+		{
+			"the fox", "the brown fox",
+			[]Piece{{Same, "the "}, {Added, "brown "}, {Same, "fox"}},
+		},
+		{
+			"a b c d", "a c d e",
+			[]Piece{{Same, "a "}, {Removed, "b "}, {Same, "c d"}, {Added, " e"}},
+		},
+		{
+			"", "new",
+			[]Piece{{Added, "new"}},
+		},
+	}
+
+	for _, test := range tests {
+		output := Words(test.before, test.after)
+		if !reflect.DeepEqual(output, test.expected) {
+			t.Fatalf("diffing %q and %q gave %v, expected %v", test.before, test.after, output, test.expected)
+		}
+	}
+}
+
+func TestSpacingIsIgnored(t *testing.T) {
+	/* Padding differs, but not where lines and paragraphs break */
+	output := Words("one  two   \nthree\n\n\nfour", "one two\nthree\n\nfour")
+	expected := []Piece{{Same, "one two\nthree\n\nfour"}}
+	if !reflect.DeepEqual(output, expected) {
+		t.Fatalf("expected %v, not %v", expected, output)
+	}
+}
@@ -59,6 +59,50 @@ func (f *Feed) Prepend(input []pub.Tangible) {
 	f.lowerBound -= len(input)
 }
 
+/* Replaces every element after the center, keeping the index within bounds */
+func (f *Feed) ReplaceChildren(input []pub.Tangible) {
+	for i := 1; i < f.upperBound; i++ {
+		delete(f.feed, i)
+	}
+	f.upperBound = 1
+	f.Append(input)
+	if f.index >= f.upperBound && f.upperBound-1 > f.lowerBound {
+		f.index = f.upperBound - 1
+	}
+}
+
+/* The index is relative to the center, which is at 0 */
+func (f *Feed) Index() int {
+	return f.index
+}
+
+/* The index of the first element, which is negative if there are parents */
+func (f *Feed) FirstIndex() int {
+	return f.lowerBound + 1
+}
+
+/* The index of the last element, which is 0 if there are no children */
+func (f *Feed) LastIndex() int {
+	return f.upperBound - 1
+}
+
+func (f *Feed) MoveTo(index int) {
+	if f.Contains(index - f.index) {
+		f.index = index
+	}
+}
+
+/* Replaces the element wherever it is, returning false if it isn't present */
+func (f *Feed) Swap(old, new pub.Tangible) bool {
+	for i, element := range f.feed {
+		if element == old {
+			f.feed[i] = new
+			return true
+		}
+	}
+	return false
+}
+
 func (f *Feed) Get(offset int) pub.Tangible {
 	if !f.Contains(offset) {
 		panic(fmt.Sprintf("indexing feed at %d whereas bounds are %d and %d", f.index+offset, f.lowerBound, f.upperBound))
@@ -140,3 +140,77 @@ func TestMoveUp(t *testing.T) {
 	}()
 	feed.Get(-1)
 }
+
+func TestReplaceChildren(t *testing.T) {
+	feed := Create(post1)
+	feed.Append([]pub.Tangible{post1, post1, post1})
+	feed.MoveDown()
+	feed.MoveDown()
+	feed.MoveDown()
+	feed.ReplaceChildren([]pub.Tangible{post2})
+	if feed.Index() != 1 {
+		t.Fatalf("index should have been clamped to 1 but is %d", feed.Index())
+	}
+	shouldBePost2 := feed.Current()
+	if shouldBePost2 != post2 {
+		t.Fatalf("is %#v but should be %#v", shouldBePost2, post2)
+	}
+	if feed.Contains(1) {
+		t.Fatalf("the replaced children should have been removed")
+	}
+}
+
+func TestMoveTo(t *testing.T) {
+	feed := Create(post1)
+	feed.Append([]pub.Tangible{post2})
+	feed.MoveTo(1)
+	shouldBePost2 := feed.Current()
+	if shouldBePost2 != post2 {
+		t.Fatalf("is %#v but should be %#v", shouldBePost2, post2)
+	}
+	feed.MoveTo(5)
+	if feed.Index() != 1 {
+		t.Fatalf("moving out of bounds should do nothing, but the index is %d", feed.Index())
+	}
+}
+
+func TestLastIndex(t *testing.T) {
+	feed := Create(post1)
+	if feed.LastIndex() != 0 {
+		t.Fatalf("a feed without children should have a last index of 0, not %d", feed.LastIndex())
+	}
+	feed.Append([]pub.Tangible{post1, post2})
+	if feed.LastIndex() != 2 {
+		t.Fatalf("last index should be 2 but is %d", feed.LastIndex())
+	}
+}
+
+func TestFirstIndex(t *testing.T) {
+	feed := Create(post1)
+	if feed.FirstIndex() != 0 {
+		t.Fatalf("a feed without parents should have a first index of 0, not %d", feed.FirstIndex())
+	}
+	feed.Prepend([]pub.Tangible{post1, post2})
+	if feed.FirstIndex() != -2 {
+		t.Fatalf("first index should be -2 but is %d", feed.FirstIndex())
+	}
+}
+
+func TestSwap(t *testing.T) {
+	feed := Create(post1)
+	feed.Append([]pub.Tangible{post2})
+	feed.MoveDown()
+	if !feed.Swap(post1, post2) {
+		t.Fatalf("swap should have found post1")
+	}
+	if feed.Index() != 1 {
+		t.Fatalf("swap should not move the index, but it is %d", feed.Index())
+	}
+	shouldBePost2 := feed.Get(-1)
+	if shouldBePost2 != post2 {
+		t.Fatalf("is %#v but should be %#v", shouldBePost2, post2)
+	}
+	if feed.Swap(post1, post2) {
+		t.Fatalf("swap should not have found post1 after it was swapped out")
+	}
+}
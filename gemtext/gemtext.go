@@ -1,6 +1,8 @@
 package gemtext
 
 import (
+	"net/url"
+	"servitor/href"
 	"servitor/style"
 	"regexp"
 	"strings"
@@ -13,15 +15,18 @@ import (
 
 type Markup struct {
 	tree        []string
+	base        *url.URL
 	cached      string
 	cachedWidth int
 }
 
-func NewMarkup(text string) (*Markup, []string, error) {
+/* Links are resolved against `base`, which may be nil */
+func NewMarkup(text string, base *url.URL) (*Markup, []string, error) {
 	lines := strings.Split(text, "\n")
-	rendered, links := renderWithLinks(lines, 80)
+	rendered, links := renderWithLinks(lines, base, 80)
 	return &Markup{
 		tree:        lines,
+		base:        base,
 		cached:      rendered,
 		cachedWidth: 80,
 	}, links, nil
@@ -31,24 +36,30 @@ func (m *Markup) Render(width int) string {
 	if m.cachedWidth == width {
 		return m.cached
 	}
-	rendered, _ := renderWithLinks(m.tree, width)
+	rendered, _ := renderWithLinks(m.tree, m.base, width)
 	m.cached = rendered
 	m.cachedWidth = width
 	return rendered
 }
 
-func renderWithLinks(lines []string, width int) (string, []string) {
+func renderWithLinks(lines []string, base *url.URL, width int) (string, []string) {
 	links := []string{}
 	result := ""
 	preformattedMode := false
 	preformattedBuffer := ""
+	preformattedLanguage := ""
 	for _, line := range lines {
 		if strings.HasPrefix(line, "```") {
 			if preformattedMode {
-				result += style.CodeBlock(strings.TrimSuffix(preformattedBuffer, "\n")) + "\n"
+				result += style.CodeBlock(style.Syntax(strings.TrimSuffix(preformattedBuffer, "\n"), preformattedLanguage)) + "\n"
 				preformattedBuffer = ""
 				preformattedMode = false
 			} else {
+				/* The alt text conventionally begins with the language */
+				preformattedLanguage = ""
+				if alt := strings.Fields(strings.TrimPrefix(line, "```")); len(alt) != 0 {
+					preformattedLanguage = alt[0]
+				}
 				preformattedMode = true
 			}
 			continue
@@ -60,13 +71,18 @@ func renderWithLinks(lines []string, width int) (string, []string) {
 		}
 
 		if match := regexp.MustCompile(`^=>[ \t]*(.*?)(?:[ \t]+(.*))?$`).FindStringSubmatch(line); len(match) == 3 {
-			uri := match[1]
+			uri, err := href.Resolve(base, match[1])
 			alt := match[2]
 			if alt == "" {
-				alt = uri
+				alt = match[1]
+			}
+			/* Unsafe links are shown as plain text */
+			if err != nil {
+				result += alt + "\n"
+				continue
 			}
 			links = append(links, uri)
-			result += style.LinkBlock(alt, len(links)) + "\n"
+			result += style.LinkBlock(alt, len(links), uri) + "\n"
 		} else if match := regexp.MustCompile(`^#[ \t]+(.*)$`).FindStringSubmatch(line); len(match) == 2 {
 			result += style.Header(match[1], 1) + "\n"
 		} else if match := regexp.MustCompile(`^##[ \t]+(.*)$`).FindStringSubmatch(line); len(match) == 2 {
@@ -84,7 +100,7 @@ func renderWithLinks(lines []string, width int) (string, []string) {
 
 	// If trailing backticks are omitted, implicitly automatically add them
 	if preformattedMode {
-		result += style.CodeBlock(strings.TrimSuffix(preformattedBuffer, "\n")) + "\n"
+		result += style.CodeBlock(style.Syntax(strings.TrimSuffix(preformattedBuffer, "\n"), preformattedLanguage)) + "\n"
 	}
 
 	return strings.Trim(result, "\n"), links
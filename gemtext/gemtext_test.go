@@ -1,6 +1,7 @@
 package gemtext
 
 import (
+	"net/url"
 	"servitor/style"
 	"testing"
 )
@@ -19,7 +20,7 @@ func TestBasic(t *testing.T) {
 =>http://example.org/
 
 ` + "```\ncode block\nhere\n```"
-	markup, links, err := NewMarkup(input)
+	markup, links, err := NewMarkup(input, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -38,11 +39,47 @@ func TestBasic(t *testing.T) {
 		style.Header("large header", 1) + "\n" +
 		style.Header("smaller header", 2) + "\n" +
 		style.Header("smallest header", 3) + "\n\n" +
-		style.LinkBlock("Wikipedia is great!", 1) + "\n\n" +
-		style.LinkBlock("http://example.org/", 2) + "\n\n" +
+		style.LinkBlock("Wikipedia is great!", 1, "https://www.wikipedia.org/") + "\n\n" +
+		style.LinkBlock("http://example.org/", 2, "http://example.org/") + "\n\n" +
 		style.CodeBlock("code block\nhere")
 
 	if expected != output {
 		t.Fatalf("expected %s not %s", expected, output)
 	}
 }
+
+func TestPreformattedLanguage(t *testing.T) {
+	input := "```python example\nreturn None\n```"
+	markup, _, err := NewMarkup(input, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output := markup.Render(50)
+	expected := style.CodeBlock(style.Syntax("return None", "python"))
+	if expected != output {
+		t.Fatalf("expected output to be %s not %s", expected, output)
+	}
+}
+
+func TestRelativeLinks(t *testing.T) {
+	base, err := url.Parse("gemini://example.org/log/index.gmi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := "=> /about.gmi About\n=> javascript:alert(1) Trap"
+	markup, links, err := NewMarkup(input, base)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(links) != 1 || links[0] != "gemini://example.org/about.gmi" {
+		t.Fatalf("expected only gemini://example.org/about.gmi, not %v", links)
+	}
+
+	output := markup.Render(50)
+	expected := style.LinkBlock("About", 1, "gemini://example.org/about.gmi") + "\nTrap"
+	if expected != output {
+		t.Fatalf("expected %s not %s", expected, output)
+	}
+}
@@ -7,9 +7,9 @@ require (
 	github.com/hashicorp/golang-lru/v2 v2.0.7
 	github.com/yuin/goldmark v1.7.4
 	golang.org/x/exp v0.0.0-20240707233637-46b078467d37
+	golang.org/x/image v0.18.0
 	golang.org/x/net v0.27.0
 	golang.org/x/sync v0.7.0
+	golang.org/x/sys v0.22.0
 	golang.org/x/term v0.22.0
 )
-
-require golang.org/x/sys v0.22.0 // indirect
@@ -0,0 +1,85 @@
+package graphics
+
+import (
+	"image"
+	"image/color"
+	"strconv"
+	"strings"
+)
+
+/* Resizes the image by averaging the pixels that fall within each new pixel */
+func scale(img image.Image, width, height int) *image.RGBA {
+	bounds := img.Bounds()
+	output := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		top := bounds.Min.Y + y*bounds.Dy()/height
+		bottom := bounds.Min.Y + (y+1)*bounds.Dy()/height
+		if bottom <= top {
+			bottom = top + 1
+		}
+		for x := 0; x < width; x++ {
+			left := bounds.Min.X + x*bounds.Dx()/width
+			right := bounds.Min.X + (x+1)*bounds.Dx()/width
+			if right <= left {
+				right = left + 1
+			}
+			var r, g, b, a, count uint64
+			for sy := top; sy < bottom; sy++ {
+				for sx := left; sx < right; sx++ {
+					pr, pg, pb, pa := img.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa)
+					count++
+				}
+			}
+			/* Premultiplied 16-bit to premultiplied 8-bit */
+			output.SetRGBA(x, y, color.RGBA{
+				R: uint8(r / count >> 8),
+				G: uint8(g / count >> 8),
+				B: uint8(b / count >> 8),
+				A: uint8(a / count >> 8),
+			})
+		}
+	}
+	return output
+}
+
+func opaque(c color.RGBA) bool {
+	return c.A >= 128
+}
+
+func colorCode(c color.RGBA) string {
+	return strconv.Itoa(int(c.R)) + ";" + strconv.Itoa(int(c.G)) + ";" + strconv.Itoa(int(c.B))
+}
+
+/*
+Draws two pixels per cell using the upper half block, with the top
+pixel as the foreground and the bottom pixel as the background.
+Transparent pixels are left as the terminal's background.
+*/
+func blocks(img *image.RGBA) string {
+	bounds := img.Bounds()
+	lines := []string{}
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += 2 {
+		var line strings.Builder
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			top := img.RGBAAt(x, y)
+			bottom := color.RGBA{}
+			if y+1 < bounds.Max.Y {
+				bottom = img.RGBAAt(x, y+1)
+			}
+			switch {
+			case opaque(top) && opaque(bottom):
+				line.WriteString("\x1b[38;2;" + colorCode(top) + "m\x1b[48;2;" + colorCode(bottom) + "m▀\x1b[0m")
+			case opaque(top):
+				line.WriteString("\x1b[38;2;" + colorCode(top) + "m▀\x1b[0m")
+			case opaque(bottom):
+				line.WriteString("\x1b[38;2;" + colorCode(bottom) + "m▄\x1b[0m")
+			default:
+				/* A space would be collapsed by wrapping, so a blank braille pattern holds the place */
+				line.WriteString("⠀")
+			}
+		}
+		lines = append(lines, line.String())
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,112 @@
+package graphics
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"math"
+	"strings"
+)
+
+/*
+	Blurhashes encode a blurred image as a handful of cosine components.
+	See: https://github.com/woltapp/blurhash/blob/master/Algorithm.md
+*/
+
+const base83 = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz#$%*+,-.:;=?@[]^_{|}~"
+
+var errInvalidBlurhash = errors.New("invalid blurhash")
+
+func decode83(text string) (int, error) {
+	value := 0
+	for _, character := range text {
+		digit := strings.IndexRune(base83, character)
+		if digit == -1 {
+			return 0, errInvalidBlurhash
+		}
+		value = value*83 + digit
+	}
+	return value, nil
+}
+
+func srgbToLinear(value int) float64 {
+	v := float64(value) / 255
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+func linearToSrgb(value float64) uint8 {
+	v := math.Max(0, math.Min(1, value))
+	if v <= 0.0031308 {
+		return uint8(v*12.92*255 + 0.5)
+	}
+	return uint8((1.055*math.Pow(v, 1/2.4)-0.055)*255 + 0.5)
+}
+
+func signPow(value, exponent float64) float64 {
+	return math.Copysign(math.Pow(math.Abs(value), exponent), value)
+}
+
+/* punch raises or lowers the contrast, where 1 is the original */
+func decodeBlurhash(hash string, width, height int, punch float64) (*image.RGBA, error) {
+	if len(hash) < 6 || width <= 0 || height <= 0 {
+		return nil, errInvalidBlurhash
+	}
+
+	sizeFlag, err := decode83(hash[0:1])
+	if err != nil {
+		return nil, err
+	}
+	componentsY := sizeFlag/9 + 1
+	componentsX := sizeFlag%9 + 1
+	if len(hash) != 4+2*componentsX*componentsY {
+		return nil, errInvalidBlurhash
+	}
+
+	quantisedMaximum, err := decode83(hash[1:2])
+	if err != nil {
+		return nil, err
+	}
+	maximum := float64(quantisedMaximum+1) / 166 * punch
+
+	components := make([][3]float64, componentsX*componentsY)
+	for i := range components {
+		if i == 0 {
+			value, err := decode83(hash[2:6])
+			if err != nil {
+				return nil, err
+			}
+			components[i] = [3]float64{srgbToLinear(value >> 16), srgbToLinear((value >> 8) & 255), srgbToLinear(value & 255)}
+			continue
+		}
+		value, err := decode83(hash[4+i*2 : 6+i*2])
+		if err != nil {
+			return nil, err
+		}
+		components[i] = [3]float64{
+			signPow(float64(value/(19*19)-9)/9, 2) * maximum,
+			signPow(float64((value/19)%19-9)/9, 2) * maximum,
+			signPow(float64(value%19-9)/9, 2) * maximum,
+		}
+	}
+
+	output := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			var r, g, b float64
+			for j := 0; j < componentsY; j++ {
+				for i := 0; i < componentsX; i++ {
+					basis := math.Cos(math.Pi*float64(x*i)/float64(width)) * math.Cos(math.Pi*float64(y*j)/float64(height))
+					component := components[i+j*componentsX]
+					r += component[0] * basis
+					g += component[1] * basis
+					b += component[2] * basis
+				}
+			}
+			output.SetRGBA(x, y, color.RGBA{linearToSrgb(r), linearToSrgb(g), linearToSrgb(b), 255})
+		}
+	}
+	return output, nil
+}
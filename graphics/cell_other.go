@@ -0,0 +1,8 @@
+//go:build !unix
+
+package graphics
+
+/* Returns the size of a cell in pixels, which is guessed */
+func cellSize() (int, int) {
+	return 10, 20
+}
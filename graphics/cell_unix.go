@@ -0,0 +1,18 @@
+//go:build unix
+
+package graphics
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+/* Returns the size of a cell in pixels, guessing if the terminal doesn't say */
+func cellSize() (int, int) {
+	size, err := unix.IoctlGetWinsize(int(os.Stdout.Fd()), unix.TIOCGWINSZ)
+	if err != nil || size.Col == 0 || size.Row == 0 || size.Xpixel == 0 || size.Ypixel == 0 {
+		return 10, 20
+	}
+	return int(size.Xpixel / size.Col), int(size.Ypixel / size.Row)
+}
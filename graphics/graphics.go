@@ -0,0 +1,213 @@
+package graphics
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"servitor/config"
+	"servitor/style"
+	"strings"
+	"sync"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+	_ "golang.org/x/image/webp"
+)
+
+/*
+	Images are drawn in one of three ways:
+	  - kitty, via the kitty graphics protocol's Unicode placeholders,
+	    which are ordinary text as far as the layout is concerned
+	  - sixel, via markers that Finalize swaps for the image once the
+	    layout is done, since sixels can't be treated as text
+	  - blocks, via the upper half block with a colour for each half,
+	    which works in any terminal with truecolor
+*/
+
+/* The most rows an image will take up */
+const maxRows = 20
+
+/* The largest image that will be downloaded */
+const maxBytes = 16 << 20
+
+/* The number of images kept in memory */
+const cacheSize = 64
+
+type entry struct {
+	done bool
+	img  image.Image
+	err  error
+
+	/* Rendered images, by width */
+	rendered map[int]string
+}
+
+var (
+	m          sync.Mutex
+	entries, _ = lru.New[string, *entry](cacheSize)
+	onLoad     = func() {}
+
+	protocol     string
+	protocolOnce sync.Once
+)
+
+/* Sets what is called once an image finishes loading, so it can be drawn */
+func OnLoad(callback func()) {
+	m.Lock()
+	defer m.Unlock()
+	onLoad = callback
+}
+
+func detect() string {
+	if config.Parsed.Media.ImageProtocol != "auto" {
+		return config.Parsed.Media.ImageProtocol
+	}
+	term := os.Getenv("TERM")
+	program := os.Getenv("TERM_PROGRAM")
+	if os.Getenv("KITTY_WINDOW_ID") != "" || term == "xterm-kitty" || program == "ghostty" {
+		return "kitty"
+	}
+	if strings.Contains(term, "sixel") || strings.HasPrefix(term, "foot") || term == "mlterm" ||
+		program == "WezTerm" || program == "mlterm" || program == "contour" {
+		return "sixel"
+	}
+	return "blocks"
+}
+
+func currentProtocol() string {
+	protocolOnce.Do(func() {
+		protocol = detect()
+	})
+	return protocol
+}
+
+/*
+Returns the image at the link drawn within `width` columns. While it loads,
+the blurhash is drawn instead if there is one, which needs the size of the
+image to be known. The second return value is false if nothing can be drawn.
+*/
+func Render(link string, blurhash string, pixelWidth, pixelHeight uint64, width int) (string, bool) {
+	if width <= 2 {
+		return "", false
+	}
+	parsed, err := url.Parse(link)
+	if err != nil || parsed.Scheme != "https" {
+		return "", false
+	}
+
+	m.Lock()
+	e, ok := entries.Get(link)
+	if !ok {
+		e = &entry{rendered: map[int]string{}}
+		entries.Add(link, e)
+		go load(link, e)
+	}
+	if e.done {
+		defer m.Unlock()
+		if e.err != nil {
+			return "", false
+		}
+		if rendered, ok := e.rendered[width]; ok {
+			return rendered, true
+		}
+		rendered := draw(e.img, width)
+		e.rendered[width] = rendered
+		return rendered, true
+	}
+	m.Unlock()
+
+	if blurhash != "" && pixelWidth != 0 && pixelHeight != 0 {
+		columns, rows := fit(int(pixelWidth), int(pixelHeight), width, 2)
+		if placeholder, err := decodeBlurhash(blurhash, columns, rows*2, 1); err == nil {
+			return blocks(placeholder), true
+		}
+	}
+	return style.Color("loading image…"), true
+}
+
+func draw(img image.Image, width int) string {
+	switch currentProtocol() {
+	case "kitty":
+		columns, rows := fit(img.Bounds().Dx(), img.Bounds().Dy(), width, 2)
+		return kitty(img, columns, rows)
+	case "sixel":
+		cellWidth, cellHeight := cellSize()
+		columns, rows := fit(img.Bounds().Dx(), img.Bounds().Dy(), width, float64(cellHeight)/float64(cellWidth))
+		return sixel(img, columns, rows, cellWidth, cellHeight)
+	default:
+		columns, rows := fit(img.Bounds().Dx(), img.Bounds().Dy(), width, 2)
+		return blocks(scale(img, columns, rows*2))
+	}
+}
+
+/*
+Returns the columns and rows an image should take up, where
+`ratio` is the height of a cell divided by its width
+*/
+func fit(pixelWidth, pixelHeight, width int, ratio float64) (int, int) {
+	if pixelWidth <= 0 || pixelHeight <= 0 {
+		return 1, 1
+	}
+	columns := width
+	if pixelWidth < columns {
+		columns = pixelWidth
+	}
+	rows := int(float64(columns)*float64(pixelHeight)/float64(pixelWidth)/ratio + 0.5)
+	if rows > maxRows {
+		rows = maxRows
+		columns = int(float64(rows)*ratio*float64(pixelWidth)/float64(pixelHeight) + 0.5)
+	}
+	if rows < 1 {
+		rows = 1
+	}
+	if columns < 1 {
+		columns = 1
+	}
+	return columns, rows
+}
+
+func load(link string, e *entry) {
+	img, err := fetch(link)
+	m.Lock()
+	e.img, e.err = img, err
+	e.done = true
+	callback := onLoad
+	m.Unlock()
+	callback()
+}
+
+var client = &http.Client{Timeout: config.Parsed.Network.Timeout}
+
+func fetch(link string) (image.Image, error) {
+	response, err := client.Get(link)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("received invalid status %d", response.StatusCode)
+	}
+	if response.ContentLength > maxBytes {
+		return nil, errors.New("image is too large to download")
+	}
+	img, _, err := image.Decode(io.LimitReader(response.Body, maxBytes))
+	return img, err
+}
+
+/* Called on the finished frame, to do what can't be done while laying it out */
+func Finalize(frame string) string {
+	switch currentProtocol() {
+	case "kitty":
+		return transmissions() + frame
+	case "sixel":
+		return placeSixels(frame)
+	default:
+		return frame
+	}
+}
@@ -0,0 +1,72 @@
+package graphics
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+func TestBlurhash(t *testing.T) {
+	decoded, err := decodeBlurhash("LEHV6nWB2yk8pyo0adR*.7kCMdnj", 8, 6, 1)
+	if err != nil {
+		t.Fatalf("failed to decode a valid blurhash: %v", err)
+	}
+	if decoded.Bounds().Dx() != 8 || decoded.Bounds().Dy() != 6 {
+		t.Fatalf("decoded blurhash should be 8x6 but is %v", decoded.Bounds())
+	}
+	/* The average colour of this blurhash is a light purple */
+	average := decoded.RGBAAt(4, 3)
+	if average.R < 100 || average.B < 100 {
+		t.Fatalf("decoded blurhash has an unexpected colour %v", average)
+	}
+
+	if _, err := decodeBlurhash("LEHV6nWB2yk8", 8, 6, 1); err == nil {
+		t.Fatalf("a blurhash of the wrong length should fail to decode")
+	}
+	if _, err := decodeBlurhash("LEHV6nWB2yk8pyo0adR*.7kCMdn\"", 8, 6, 1); err == nil {
+		t.Fatalf("a blurhash with an invalid character should fail to decode")
+	}
+}
+
+func TestBlocks(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 3, 3))
+	for x := 0; x < 3; x++ {
+		img.SetRGBA(x, 0, color.RGBA{255, 0, 0, 255})
+		img.SetRGBA(x, 1, color.RGBA{0, 0, 255, 255})
+	}
+	output := blocks(img)
+	lines := strings.Split(output, "\n")
+	if len(lines) != 2 {
+		t.Fatalf("3 rows of pixels should take 2 lines, not %d", len(lines))
+	}
+	if !strings.Contains(lines[0], "\x1b[38;2;255;0;0m\x1b[48;2;0;0;255m▀") {
+		t.Fatalf("the first line should have red over blue, but is %q", lines[0])
+	}
+	if strings.Count(lines[1], "⠀") != 3 {
+		t.Fatalf("transparent pixels should be left blank, but the last line is %q", lines[1])
+	}
+}
+
+func TestFit(t *testing.T) {
+	columns, rows := fit(1000, 500, 40, 2)
+	if columns != 40 || rows != 10 {
+		t.Fatalf("a 2:1 image in 40 columns should take 40x10, not %dx%d", columns, rows)
+	}
+	columns, rows = fit(100, 1000, 40, 2)
+	if rows != maxRows || columns != 4 {
+		t.Fatalf("a tall image should be limited to %d rows and shrink to fit, but is %dx%d", maxRows, columns, rows)
+	}
+	columns, rows = fit(8, 8, 40, 2)
+	if columns != 8 || rows != 4 {
+		t.Fatalf("a small image shouldn't be enlarged, but is %dx%d", columns, rows)
+	}
+}
+
+func TestWriteRuns(t *testing.T) {
+	var output strings.Builder
+	writeRuns(&output, []byte("??????~~A"))
+	if output.String() != "!6?~~A" {
+		t.Fatalf("runs should be compressed when longer than 3, but got %q", output.String())
+	}
+}
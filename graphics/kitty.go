@@ -0,0 +1,92 @@
+package graphics
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/png"
+	"strconv"
+	"strings"
+)
+
+/*
+	Kitty draws an image wherever the placeholder character appears in
+	the colour that encodes the image's id. The first cell of each row
+	carries diacritics giving its row and column; kitty infers the rest.
+	See: https://sw.kovidgoyal.net/kitty/graphics-protocol/#unicode-placeholders
+*/
+
+const placeholder = '\U0010EEEE'
+
+/* The first of the diacritics that encode row and column numbers, from kitty's rowcolumn-diacritics.txt */
+var diacritics = []rune{
+	0x0305, 0x030D, 0x030E, 0x0310, 0x0312, 0x033D, 0x033E, 0x033F, 0x0346, 0x034A,
+	0x034B, 0x034C, 0x0350, 0x0351, 0x0352, 0x0357, 0x035B, 0x0363, 0x0364, 0x0365,
+	0x0366, 0x0367, 0x0368, 0x0369, 0x036A, 0x036B, 0x036C, 0x036D, 0x036E, 0x036F,
+}
+
+/* The largest chunk of data that may be sent in one escape sequence */
+const chunkSize = 4096
+
+/* These are guarded by m */
+var (
+	kittyID uint32
+	pending strings.Builder
+)
+
+/* Expects m to be held */
+func kitty(img image.Image, columns, rows int) string {
+	if rows > len(diacritics) {
+		rows = len(diacritics)
+	}
+	kittyID = kittyID%0xFFFFFF + 1
+	id := kittyID
+
+	/* There is no need to send more detail than a cell can show */
+	bounds := img.Bounds()
+	if bounds.Dx() > columns*16 {
+		img = scale(img, columns*16, bounds.Dy()*columns*16/bounds.Dx())
+	}
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, img); err != nil {
+		return ""
+	}
+	data := base64.StdEncoding.EncodeToString(encoded.Bytes())
+
+	control := "a=T,U=1,f=100,q=2,i=" + strconv.Itoa(int(id)) + ",c=" + strconv.Itoa(columns) + ",r=" + strconv.Itoa(rows)
+	for len(data) > 0 {
+		chunk := data
+		if len(chunk) > chunkSize {
+			chunk = chunk[:chunkSize]
+		}
+		data = data[len(chunk):]
+		more := "1"
+		if len(data) == 0 {
+			more = "0"
+		}
+		if control != "" {
+			pending.WriteString("\x1b_G" + control + ",m=" + more + ";" + chunk + "\x1b\\")
+			control = ""
+		} else {
+			pending.WriteString("\x1b_Gm=" + more + ";" + chunk + "\x1b\\")
+		}
+	}
+
+	color := "\x1b[38;2;" + strconv.Itoa(int(id>>16&255)) + ";" + strconv.Itoa(int(id>>8&255)) + ";" + strconv.Itoa(int(id&255)) + "m"
+	lines := make([]string, rows)
+	for row := range lines {
+		line := string(placeholder) + string(diacritics[row]) + string(diacritics[0])
+		line += strings.Repeat(string(placeholder), columns-1)
+		lines[row] = color + line + "\x1b[0m"
+	}
+	return strings.Join(lines, "\n")
+}
+
+/* Returns the images that have yet to be sent to the terminal */
+func transmissions() string {
+	m.Lock()
+	defer m.Unlock()
+	output := pending.String()
+	pending.Reset()
+	return output
+}
@@ -0,0 +1,165 @@
+package graphics
+
+import (
+	"image"
+	"strconv"
+	"strings"
+)
+
+/*
+	Sixels can't be laid out as text, so each is represented by a block
+	of marker characters the size of the image. The first marker identifies
+	the image and the rest are filler. Once the frame is laid out, the first
+	is replaced by the image and the filler by cursor movements, so the
+	image isn't drawn over.
+*/
+
+const (
+	firstMarker = '\U000F0000'
+	lastMarker  = '\U000FFFFD'
+	filler      = '\U0010FFFD'
+)
+
+/* These are guarded by m */
+var (
+	sixelID uint32
+	sixels  = map[rune]string{}
+)
+
+/* Expects m to be held */
+func sixel(img image.Image, columns, rows, cellWidth, cellHeight int) string {
+	sixelID = sixelID%(lastMarker-firstMarker) + 1
+	marker := firstMarker + rune(sixelID)
+
+	bounds := img.Bounds()
+	width := columns * cellWidth
+	height := width * bounds.Dy() / bounds.Dx()
+	if height > rows*cellHeight {
+		height = rows * cellHeight
+		width = height * bounds.Dx() / bounds.Dy()
+	}
+	if width < 1 {
+		width = 1
+	}
+	if height < 1 {
+		height = 1
+	}
+	sixels[marker] = encodeSixel(scale(img, width, height))
+
+	lines := make([]string, rows)
+	for row := range lines {
+		if row == 0 {
+			lines[row] = string(marker) + strings.Repeat(string(filler), columns-1)
+		} else {
+			lines[row] = strings.Repeat(string(filler), columns)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+/* The sixels use a palette of 6 levels of each primary */
+func paletteIndex(r, g, b uint8) int {
+	return int(r)*6/256*36 + int(g)*6/256*6 + int(b)*6/256
+}
+
+func encodeSixel(img *image.RGBA) string {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	indices := make([]int, width*height)
+	used := [216]bool{}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			c := img.RGBAAt(bounds.Min.X+x, bounds.Min.Y+y)
+			if !opaque(c) {
+				indices[y*width+x] = -1
+				continue
+			}
+			index := paletteIndex(c.R, c.G, c.B)
+			indices[y*width+x] = index
+			used[index] = true
+		}
+	}
+
+	var output strings.Builder
+	/* Pixels without a colour are left transparent */
+	output.WriteString("\x1bP0;1;0q\"1;1;" + strconv.Itoa(width) + ";" + strconv.Itoa(height))
+	for index, isUsed := range used {
+		if !isUsed {
+			continue
+		}
+		output.WriteString("#" + strconv.Itoa(index) + ";2;" +
+			strconv.Itoa(index/36*100/5) + ";" +
+			strconv.Itoa(index/6%6*100/5) + ";" +
+			strconv.Itoa(index%6*100/5))
+	}
+
+	for band := 0; band < height; band += 6 {
+		first := true
+		for index, isUsed := range used {
+			if !isUsed {
+				continue
+			}
+			row := make([]byte, width)
+			present := false
+			for x := 0; x < width; x++ {
+				var bits byte
+				for k := 0; k < 6 && band+k < height; k++ {
+					if indices[(band+k)*width+x] == index {
+						bits |= 1 << k
+					}
+				}
+				row[x] = 63 + bits
+				present = present || bits != 0
+			}
+			if !present {
+				continue
+			}
+			if !first {
+				/* Return to the start of the band to draw the next colour */
+				output.WriteByte('$')
+			}
+			first = false
+			output.WriteString("#" + strconv.Itoa(index))
+			writeRuns(&output, row)
+		}
+		output.WriteByte('-')
+	}
+	output.WriteString("\x1b\\")
+	return output.String()
+}
+
+/* Writes the row with runs compressed */
+func writeRuns(output *strings.Builder, row []byte) {
+	for start := 0; start < len(row); {
+		end := start
+		for end < len(row) && row[end] == row[start] {
+			end++
+		}
+		if count := end - start; count > 3 {
+			output.WriteString("!" + strconv.Itoa(count))
+			output.WriteByte(row[start])
+		} else {
+			output.WriteString(strings.Repeat(string(row[start]), count))
+		}
+		start = end
+	}
+}
+
+func placeSixels(frame string) string {
+	m.Lock()
+	defer m.Unlock()
+	var output strings.Builder
+	for _, character := range frame {
+		switch {
+		case character == filler:
+			output.WriteString("\x1b[C")
+		case character > firstMarker && character <= lastMarker:
+			/* The cursor is saved and restored since terminals differ in where they leave it */
+			output.WriteString("\x1b7" + sixels[character] + "\x1b8\x1b[C")
+		default:
+			output.WriteRune(character)
+		}
+	}
+	return output.String()
+}
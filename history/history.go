@@ -31,6 +31,15 @@ func (h *History[T]) Add(element T) {
 	h.index += 1
 }
 
+/* Replaces the current element without discarding forward history */
+func (h *History[T]) Replace(element T) {
+	if h.elements == nil {
+		h.Add(element)
+		return
+	}
+	h.elements[h.index] = element
+}
+
 func (h *History[T]) IsEmpty() bool {
 	return len(h.elements) == 0
 }
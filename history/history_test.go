@@ -75,3 +75,17 @@ func TestForwardDestruction(t *testing.T) {
 		t.Fatalf("current should be 3 not %v after forward destruction", current)
 	}
 }
+
+func TestReplace(t *testing.T) {
+	h := History[int]{}
+	h.Add(1)
+	h.Add(2)
+	h.Back()
+	h.Replace(3)
+	replaced := h.Current()
+	h.Forward()
+	forward := h.Current()
+	if replaced != 3 || forward != 2 {
+		t.Fatalf("replaced should be 3 not %v, forward should be 2 not %v", replaced, forward)
+	}
+}
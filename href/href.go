@@ -0,0 +1,44 @@
+package href
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+/*
+	Links found in markup are resolved against the id of the object
+	the markup belongs to, since WriteFreely, Plume and gemtext often
+	use relative links. Only schemes that are safe to hand to a browser
+	or media player are kept; javascript: and the like are refused.
+*/
+
+var ErrUnsafe = errors.New("link has an unsafe scheme")
+
+var safeSchemes = []string{
+	"http", "https", "gemini", "gopher", "mailto", "xmpp", "matrix", "magnet", "ftp",
+}
+
+/* Resolves `reference` against `base`, which may be nil if the markup has no known location */
+func Resolve(base *url.URL, reference string) (string, error) {
+	parsed, err := url.Parse(strings.TrimSpace(reference))
+	if err != nil {
+		return "", err
+	}
+	if base != nil {
+		parsed = base.ResolveReference(parsed)
+	}
+
+	/* Without a base, relative links are left as they are */
+	if parsed.Scheme == "" && base == nil {
+		return parsed.String(), nil
+	}
+
+	scheme := strings.ToLower(parsed.Scheme)
+	for _, safe := range safeSchemes {
+		if scheme == safe {
+			return parsed.String(), nil
+		}
+	}
+	return "", ErrUnsafe
+}
@@ -0,0 +1,45 @@
+package href
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	base, err := url.Parse("https://example.org/posts/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		base      *url.URL
+		reference string
+		expected  string
+	}{
+		{base, "/foo", "https://example.org/foo"},
+		{base, "other", "https://example.org/posts/other"},
+		{base, "#note", "https://example.org/posts/1#note"},
+		{base, "https://elsewhere.com/", "https://elsewhere.com/"},
+		{base, "gemini://example.org/", "gemini://example.org/"},
+		{nil, "/foo", "/foo"},
+	}
+
+	for _, test := range tests {
+		resolved, err := Resolve(test.base, test.reference)
+		if err != nil {
+			t.Fatalf("failed to resolve %s: %v", test.reference, err)
+		}
+		if resolved != test.expected {
+			t.Fatalf("expected %s to resolve to %s not %s", test.reference, test.expected, resolved)
+		}
+	}
+}
+
+func TestUnsafe(t *testing.T) {
+	for _, reference := range []string{"javascript:alert(1)", " JavaScript:alert(1)", "data:text/html,hi", "file:///etc/passwd"} {
+		if _, err := Resolve(nil, reference); !errors.Is(err, ErrUnsafe) {
+			t.Fatalf("expected %q to be unsafe, not %v", reference, err)
+		}
+	}
+}
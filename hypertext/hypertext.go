@@ -2,26 +2,62 @@ package hypertext
 
 import (
 	"golang.org/x/net/html"
+	"golang.org/x/exp/slices"
 	"golang.org/x/net/html/atom"
+	"net/url"
+	"regexp"
 	"servitor/ansi"
+	"servitor/graphics"
+	"servitor/href"
 	"servitor/style"
-	"regexp"
+	"strconv"
 	"strings"
 )
 
 type Markup struct {
 	tree        []*html.Node
+	offset      int
+	/* Where the markup came from, which relative links are resolved against */
+	base        *url.URL
 	cached      string
 	cachedWidth int
+	/* Whether the contents of details elements are shown */
+	detailsOpen bool
+	kinds       []LinkKind
 }
 
+/* What a link points to, as far as the markup says */
+type LinkKind int
+
+const (
+	External LinkKind = iota
+	Mention
+	Hashtag
+)
+
 type context struct {
 	preserveWhitespace bool
 	width              int
 	links              *[]string
+	kinds              *[]LinkKind
+	base               *url.URL
+	/* Whether images are drawn above their alt text */
+	images      bool
+	detailsOpen bool
+	/* The number of lists the node is nested within */
+	listDepth int
 }
 
-func NewMarkup(text string) (*Markup, []string, error) {
+/* Links are resolved against `base`, which may be nil */
+func NewMarkup(text string, base *url.URL) (*Markup, []string, error) {
+	return NewMarkupWithOffset(text, base, 0)
+}
+
+/*
+Like NewMarkup, but links are numbered starting after `offset`,
+for markup that is displayed below other markup
+*/
+func NewMarkupWithOffset(text string, base *url.URL, offset int) (*Markup, []string, error) {
 	nodes, err := html.ParseFragment(strings.NewReader(text), &html.Node{
 		Type:     html.ElementNode,
 		Data:     "body",
@@ -30,37 +66,95 @@ func NewMarkup(text string) (*Markup, []string, error) {
 	if err != nil {
 		return nil, []string{}, err
 	}
-	rendered, links := renderWithLinks(nodes, 80)
-	return &Markup{
-		tree:        nodes,
-		cached:      rendered,
-		cachedWidth: 80,
-	}, links, nil
+	m := &Markup{
+		tree:   nodes,
+		offset: offset,
+		base:   base,
+	}
+	rendered, links, kinds := m.renderWithLinks(80, false)
+	m.cached = rendered
+	m.cachedWidth = 80
+	m.kinds = kinds
+	return m, links, nil
+}
+
+/* The kinds of the links, in the same order as the links themselves */
+func (m *Markup) LinkKinds() []LinkKind {
+	return m.kinds
+}
+
+/* Whether the link at the index leads to a mention or hashtag rather than the web */
+func (m *Markup) IsMentionOrHashtag(index int) bool {
+	return index >= 0 && index < len(m.kinds) && m.kinds[index] != External
 }
 
 func (m *Markup) Render(width int) string {
 	if m.cachedWidth == width {
 		return m.cached
 	}
-	rendered, _ := renderWithLinks(m.tree, width)
+	rendered, _, _ := m.renderWithLinks(width, false)
 	m.cachedWidth = width
 	m.cached = rendered
 	return rendered
 }
 
-func renderWithLinks(nodes []*html.Node, width int) (string, []string) {
+/* Like Render, but draws images inline; not cached since images change as they load */
+func (m *Markup) RenderWithImages(width int) string {
+	rendered, _, _ := m.renderWithLinks(width, true)
+	return rendered
+}
+
+/* Expands or collapses the details elements, returning false if there are none */
+func (m *Markup) ToggleDetails() bool {
+	found := false
+	var visit func(node *html.Node)
+	visit = func(node *html.Node) {
+		if node.Type == html.ElementNode && node.Data == "details" {
+			found = true
+			return
+		}
+		for current := node.FirstChild; current != nil && !found; current = current.NextSibling {
+			visit(current)
+		}
+	}
+	for _, node := range m.tree {
+		visit(node)
+	}
+	if !found {
+		return false
+	}
+	m.detailsOpen = !m.detailsOpen
+	m.cachedWidth = -1
+	return true
+}
+
+func (m *Markup) renderWithLinks(width int, images bool) (string, []string, []LinkKind) {
+	/* The placeholders shift the numbering of the links */
+	links := make([]string, m.offset)
+	kinds := make([]LinkKind, m.offset)
 	ctx := context{
 		preserveWhitespace: false,
 		width:              width,
-		links:              &[]string{},
+		links:              &links,
+		kinds:              &kinds,
+		base:               m.base,
+		images:             images,
+		detailsOpen:        m.detailsOpen,
 	}
 	output := ""
-	for _, current := range nodes {
+	for _, current := range m.tree {
 		result := renderNode(current, ctx)
 		output = mergeText(output, result)
 	}
 	output = ansi.Wrap(output, width)
-	return strings.Trim(output, " \n"), *ctx.links
+	return strings.Trim(output, " \n"), (*ctx.links)[m.offset:], (*ctx.kinds)[m.offset:]
+}
+
+/* Adds a link to those found, returning its number */
+func (ctx context) register(link string, kind LinkKind) int {
+	*ctx.links = append(*ctx.links, link)
+	*ctx.kinds = append(*ctx.kinds, kind)
+	return len(*ctx.links)
 }
 
 /*
@@ -118,16 +212,38 @@ func renderNode(node *html.Node, ctx context) string {
 		return ""
 	}
 
+	/* Quoted posts are displayed separately, so their fallback "RE: <link>" is hidden */
+	if hasClass("quote-inline", node.Attr) {
+		return ""
+	}
+
+	/* Mastodon shortens long URLs by hiding their ends, e.g. the https:// */
+	if hasClass("invisible", node.Attr) {
+		return ""
+	}
+	if hasClass("ellipsis", node.Attr) {
+		return renderChildren(node, ctx) + "…"
+	}
+
 	switch node.Data {
 	case "a":
-		link := getAttribute("href", node.Attr)
-		if link == "" {
+		reference := getAttribute("href", node.Attr)
+		if reference == "" {
+			return renderChildren(node, ctx)
+		}
+		link, err := href.Resolve(ctx.base, reference)
+		/* Unsafe links are shown as plain text */
+		if err != nil {
 			return renderChildren(node, ctx)
 		}
-		*ctx.links = append(*ctx.links, link)
+		kind := linkKind(node)
+		number := ctx.register(link, kind)
 		/* This must occur before the styling because it mutates ctx.links */
 		rendered := renderChildren(node, ctx)
-		return style.Link(rendered, len(*ctx.links))
+		if kind != External {
+			return style.Mention(rendered, number, link)
+		}
+		return style.Link(rendered, number, link)
 	case "s", "del":
 		return style.Strikethrough(renderChildren(node, ctx))
 	case "code":
@@ -141,8 +257,27 @@ func renderNode(node *html.Node, ctx context) string {
 		return style.Underline(renderChildren(node, ctx))
 	case "mark":
 		return style.Highlight(renderChildren(node, ctx))
-	case "span":
+	case "span", "small":
 		return renderChildren(node, ctx)
+	case "cite", "var", "dfn":
+		return style.Italic(renderChildren(node, ctx))
+	case "kbd", "samp":
+		ctx.preserveWhitespace = true
+		return style.Code(renderChildren(node, ctx))
+	case "q":
+		return "\u201c" + renderChildren(node, ctx) + "\u201d"
+	case "abbr":
+		rendered := renderChildren(node, ctx)
+		if title := getAttribute("title", node.Attr); title != "" {
+			return rendered + " (" + title + ")"
+		}
+		return rendered
+	case "sup":
+		return script(renderChildren(node, ctx), superscripts, "^")
+	case "sub":
+		return script(renderChildren(node, ctx), subscripts, "_")
+	case "ruby":
+		return ruby(node, ctx)
 	case "li":
 		return strings.Trim(renderChildren(node, ctx), " \n")
 	case "br":
@@ -152,7 +287,11 @@ func renderNode(node *html.Node, ctx context) string {
 		return block(renderChildren(node, ctx))
 	case "pre":
 		ctx.preserveWhitespace = true
-		wrapped := ansi.Pad(situationalWrap(renderChildren(node, ctx), ctx), ctx.width)
+		contents := renderChildren(node, ctx)
+		if code, ok := plainCode(node); ok {
+			contents = style.Syntax(code, codeLanguage(node))
+		}
+		wrapped := ansi.Pad(situationalWrap(contents, ctx), ctx.width)
 		return block(style.CodeBlock(wrapped))
 	case "blockquote":
 		ctx.width -= 1
@@ -160,8 +299,19 @@ func renderNode(node *html.Node, ctx context) string {
 		return block(style.QuoteBlock(strings.Trim(wrapped, " \n")))
 	case "ul":
 		return bulletedList(node, ctx)
-	// case "ul":
-	// 	return numberedList(node)
+	case "ol":
+		return numberedList(node, ctx)
+	case "dl":
+		return definitionList(node, ctx)
+	case "table":
+		return renderTable(node, ctx)
+	case "details":
+		return details(node, ctx)
+	case "figure":
+		return block(renderChildren(node, ctx))
+	case "figcaption":
+		wrapped := situationalWrap(style.Italic(renderChildren(node, ctx)), ctx)
+		return block(wrapped)
 	case "h1":
 		ctx.width -= 2
 		wrapped := situationalWrap(renderChildren(node, ctx), ctx)
@@ -200,30 +350,39 @@ func renderNode(node *html.Node, ctx context) string {
 	*/
 	case "img", "video", "audio":
 		alt := getAttribute("alt", node.Attr)
-		link := getAttribute("src", node.Attr)
+		link, err := resolveSource(node, ctx)
 		if alt == "" {
 			alt = link
 		}
-		if link == "" {
+		if link == "" || err != nil {
 			return block(alt)
 		}
-		*ctx.links = append(*ctx.links, link)
+		number := ctx.register(link, External)
+		image, drawn := "", false
+		if ctx.images && node.Data == "img" {
+			width, _ := strconv.ParseUint(getAttribute("width", node.Attr), 10, 64)
+			height, _ := strconv.ParseUint(getAttribute("height", node.Attr), 10, 64)
+			image, drawn = graphics.Render(link, "", width, height, ctx.width)
+		}
 		ctx.width -= 2
 		wrapped := situationalWrap(alt, ctx)
-		return block(style.LinkBlock(wrapped, len(*ctx.links)))
+		if drawn {
+			return block(image + "\n" + style.LinkBlock(wrapped, number, link))
+		}
+		return block(style.LinkBlock(wrapped, number, link))
 	case "iframe":
 		alt := getAttribute("title", node.Attr)
-		link := getAttribute("src", node.Attr)
+		link, err := resolveSource(node, ctx)
 		if alt == "" {
 			alt = link
 		}
-		if link == "" {
+		if link == "" || err != nil {
 			return block(alt)
 		}
-		*ctx.links = append(*ctx.links, link)
+		number := ctx.register(link, External)
 		ctx.width -= 2
 		wrapped := situationalWrap(alt, ctx)
-		return block(style.LinkBlock(wrapped, len(*ctx.links)))
+		return block(style.LinkBlock(wrapped, number, link))
 	default:
 		return bad(node, ctx)
 	}
@@ -245,6 +404,7 @@ func block(text string) string {
 func bulletedList(node *html.Node, ctx context) string {
 	output := ""
 	ctx.width -= 2
+	ctx.listDepth += 1
 	for current := node.FirstChild; current != nil; current = current.NextSibling {
 		if current.Type != html.ElementNode {
 			continue
@@ -267,6 +427,233 @@ func bulletedList(node *html.Node, ctx context) string {
 	return block(output)
 }
 
+func numberedList(node *html.Node, ctx context) string {
+	items := []*html.Node{}
+	for current := node.FirstChild; current != nil; current = current.NextSibling {
+		if current.Type == html.ElementNode {
+			items = append(items, current)
+		}
+	}
+
+	step := 1
+	number := 1
+	if hasAttribute("reversed", node.Attr) {
+		step = -1
+		number = len(items)
+	}
+	if start, err := strconv.Atoi(getAttribute("start", node.Attr)); err == nil {
+		number = start
+	}
+	/* Nested lists count with letters and then numerals unless told otherwise */
+	kind := getAttribute("type", node.Attr)
+	if kind == "" {
+		kind = []string{"1", "a", "i"}[ctx.listDepth%3]
+	}
+
+	markers := make([]string, len(items))
+	markerWidth := 0
+	for i, item := range items {
+		if value, err := strconv.Atoi(getAttribute("value", item.Attr)); err == nil && item.Data == "li" {
+			number = value
+		}
+		markers[i] = listMarker(number, kind) + "."
+		if length := len([]rune(markers[i])); length > markerWidth {
+			markerWidth = length
+		}
+		number += step
+	}
+
+	output := ""
+	ctx.width -= markerWidth + 1
+	ctx.listDepth += 1
+	for i, item := range items {
+		result := ""
+		if item.Data != "li" {
+			result = bad(item, ctx)
+		} else {
+			result = renderNode(item, ctx)
+		}
+
+		wrapped := situationalWrap(result, ctx)
+		marker := strings.Repeat(" ", markerWidth-len([]rune(markers[i]))) + markers[i]
+		output += "\n" + marker + " " + ansi.Indent(wrapped, strings.Repeat(" ", markerWidth+1), false)
+	}
+
+	if node.Parent != nil && node.Parent.Data == "li" {
+		return output
+	}
+	return block(output)
+}
+
+/* Formats the number of a list item according to the list's type attribute */
+func listMarker(number int, kind string) string {
+	switch kind {
+	case "a", "A":
+		if number < 1 {
+			break
+		}
+		letters := ""
+		for n := number; n > 0; n = (n - 1) / 26 {
+			letters = string(rune('a'+(n-1)%26)) + letters
+		}
+		if kind == "A" {
+			return strings.ToUpper(letters)
+		}
+		return letters
+	case "i", "I":
+		if number < 1 || number > 3999 {
+			break
+		}
+		numeral := ""
+		n := number
+		for _, pair := range []struct {
+			value  int
+			symbol string
+		}{
+			{1000, "m"}, {900, "cm"}, {500, "d"}, {400, "cd"}, {100, "c"}, {90, "xc"},
+			{50, "l"}, {40, "xl"}, {10, "x"}, {9, "ix"}, {5, "v"}, {4, "iv"}, {1, "i"},
+		} {
+			for n >= pair.value {
+				numeral += pair.symbol
+				n -= pair.value
+			}
+		}
+		if kind == "I" {
+			return strings.ToUpper(numeral)
+		}
+		return numeral
+	}
+	return strconv.Itoa(number)
+}
+
+/* Terms are bold, with their descriptions indented beneath them */
+func definitionList(node *html.Node, ctx context) string {
+	output := ""
+	for current := node.FirstChild; current != nil; current = current.NextSibling {
+		if current.Type != html.ElementNode {
+			continue
+		}
+		switch current.Data {
+		case "dt":
+			output += "\n" + situationalWrap(style.Bold(strings.Trim(renderChildren(current, ctx), " \n")), ctx)
+		case "dd":
+			inner := ctx
+			inner.width -= 2
+			wrapped := situationalWrap(strings.Trim(renderChildren(current, inner), " \n"), inner)
+			output += "\n" + ansi.Indent(wrapped, "  ", true)
+		/* Groups of terms and descriptions may be wrapped in divs */
+		case "div":
+			output += definitionList(current, ctx)
+		default:
+			output += "\n" + bad(current, ctx)
+		}
+	}
+	if node.Data == "div" {
+		return output
+	}
+	return block(output)
+}
+
+/*
+Shows the summary, and the rest only once details are expanded. The rest
+is rendered either way, so links are numbered the same whether it is shown
+*/
+func details(node *html.Node, ctx context) string {
+	ctx.width -= 2
+	summary := ""
+	contents := ""
+	for current := node.FirstChild; current != nil; current = current.NextSibling {
+		if summary == "" && current.Type == html.ElementNode && current.Data == "summary" {
+			summary = strings.Trim(renderChildren(current, ctx), " \n")
+			continue
+		}
+		contents = mergeText(contents, renderNode(current, ctx))
+	}
+	if summary == "" {
+		summary = "Details"
+	}
+	summary = situationalWrap(summary, ctx)
+
+	if !ctx.detailsOpen && !hasAttribute("open", node.Attr) {
+		return block(style.Color("\u25B8 ") + ansi.Indent(summary, "  ", false))
+	}
+	wrapped := situationalWrap(strings.Trim(contents, " \n"), ctx)
+	return block(style.Color("\u25BE ") + ansi.Indent(summary, "  ", false) + "\n" + ansi.Indent(wrapped, "  ", true))
+}
+
+var superscripts = map[rune]rune{
+	'0': '⁰', '1': '¹', '2': '²', '3': '³', '4': '⁴', '5': '⁵', '6': '⁶', '7': '⁷', '8': '⁸', '9': '⁹',
+	'+': '⁺', '-': '⁻', '=': '⁼', '(': '⁽', ')': '⁾',
+	'a': 'ᵃ', 'b': 'ᵇ', 'c': 'ᶜ', 'd': 'ᵈ', 'e': 'ᵉ', 'f': 'ᶠ', 'g': 'ᵍ', 'h': 'ʰ', 'i': 'ⁱ', 'j': 'ʲ',
+	'k': 'ᵏ', 'l': 'ˡ', 'm': 'ᵐ', 'n': 'ⁿ', 'o': 'ᵒ', 'p': 'ᵖ', 'r': 'ʳ', 's': 'ˢ', 't': 'ᵗ', 'u': 'ᵘ',
+	'v': 'ᵛ', 'w': 'ʷ', 'x': 'ˣ', 'y': 'ʸ', 'z': 'ᶻ',
+}
+
+var subscripts = map[rune]rune{
+	'0': '₀', '1': '₁', '2': '₂', '3': '₃', '4': '₄', '5': '₅', '6': '₆', '7': '₇', '8': '₈', '9': '₉',
+	'+': '₊', '-': '₋', '=': '₌', '(': '₍', ')': '₎',
+	'a': 'ₐ', 'e': 'ₑ', 'h': 'ₕ', 'i': 'ᵢ', 'j': 'ⱼ', 'k': 'ₖ', 'l': 'ₗ', 'm': 'ₘ', 'n': 'ₙ', 'o': 'ₒ',
+	'p': 'ₚ', 'r': 'ᵣ', 's': 'ₛ', 't': 'ₜ', 'u': 'ᵤ', 'v': 'ᵥ', 'x': 'ₓ',
+}
+
+var escapeSequence = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+/*
+Raises or lowers the text with Unicode's super and subscript characters,
+falling back to a marker like x^2 when some character has no equivalent
+*/
+func script(text string, table map[rune]rune, marker string) string {
+	converted := ""
+	position := 0
+	convert := func(segment string) bool {
+		for _, character := range segment {
+			if replacement, ok := table[character]; ok {
+				converted += string(replacement)
+			} else if character == ' ' || character == '\u00A0' {
+				converted += string(character)
+			} else {
+				return false
+			}
+		}
+		return true
+	}
+	/* The styling is kept as it is */
+	for _, location := range escapeSequence.FindAllStringIndex(text, -1) {
+		if !convert(text[position:location[0]]) {
+			return fallbackScript(text, marker)
+		}
+		converted += text[location[0]:location[1]]
+		position = location[1]
+	}
+	if !convert(text[position:]) {
+		return fallbackScript(text, marker)
+	}
+	return converted
+}
+
+func fallbackScript(text string, marker string) string {
+	if strings.ContainsAny(text, " \n") {
+		return marker + "(" + text + ")"
+	}
+	return marker + text
+}
+
+/* Annotations follow the text they annotate in parentheses, which is what rp elements are for */
+func ruby(node *html.Node, ctx context) string {
+	output := ""
+	for current := node.FirstChild; current != nil; current = current.NextSibling {
+		if current.Type == html.ElementNode && current.Data == "rp" {
+			continue
+		}
+		if current.Type == html.ElementNode && current.Data == "rt" {
+			output += "(" + strings.Trim(renderChildren(current, ctx), " \n") + ")"
+			continue
+		}
+		output += renderNode(current, ctx)
+	}
+	return output
+}
+
 func bad(node *html.Node, ctx context) string {
 	return style.Red("<"+node.Data+">") + renderChildren(node, ctx) + style.Red("</"+node.Data+">")
 }
@@ -280,6 +667,81 @@ func getAttribute(name string, attributes []html.Attribute) string {
 	return ""
 }
 
+/* The language of a code block, from a class like language-go on it or its code element */
+func codeLanguage(node *html.Node) string {
+	for current := node; current != nil; current = current.FirstChild {
+		for _, class := range strings.Fields(getAttribute("class", current.Attr)) {
+			for _, prefix := range []string{"language-", "lang-"} {
+				if strings.HasPrefix(class, prefix) {
+					return strings.TrimPrefix(class, prefix)
+				}
+			}
+		}
+		if current != node && current.Data != "code" {
+			break
+		}
+	}
+	return ""
+}
+
+/* The text of a code block, which can only be highlighted if it has no links or other markup */
+func plainCode(node *html.Node) (string, bool) {
+	text := ""
+	for current := node.FirstChild; current != nil; current = current.NextSibling {
+		switch {
+		case current.Type == html.TextNode:
+			text += current.Data
+		case current.Type == html.ElementNode && (current.Data == "code" || current.Data == "span"):
+			inner, ok := plainCode(current)
+			if !ok {
+				return "", false
+			}
+			text += inner
+		default:
+			return "", false
+		}
+	}
+	return text, true
+}
+
+func resolveSource(node *html.Node, ctx context) (string, error) {
+	reference := getAttribute("src", node.Attr)
+	if reference == "" {
+		return "", nil
+	}
+	return href.Resolve(ctx.base, reference)
+}
+
+/* Mastodon marks mentions with mention or u-url, and hashtags with hashtag or rel="tag" as well */
+func linkKind(node *html.Node) LinkKind {
+	if hasClass("hashtag", node.Attr) || slices.Contains(strings.Fields(getAttribute("rel", node.Attr)), "tag") {
+		return Hashtag
+	}
+	if hasClass("mention", node.Attr) || hasClass("u-url", node.Attr) {
+		return Mention
+	}
+	return External
+}
+
+/* For boolean attributes, which are present but empty */
+func hasAttribute(name string, attributes []html.Attribute) bool {
+	for _, attribute := range attributes {
+		if attribute.Key == name {
+			return true
+		}
+	}
+	return false
+}
+
+func hasClass(name string, attributes []html.Attribute) bool {
+	for _, class := range strings.Fields(getAttribute("class", attributes)) {
+		if class == name {
+			return true
+		}
+	}
+	return false
+}
+
 func situationalWrap(text string, ctx context) string {
 	if ctx.preserveWhitespace {
 		return ansi.DumbWrap(text, ctx.width)
@@ -1,6 +1,7 @@
 package hypertext
 
 import (
+	"net/url"
 	"servitor/ansi"
 	"servitor/style"
 	"testing"
@@ -42,7 +43,7 @@ func TestMergeText(t *testing.T) {
 
 func TestStyles(t *testing.T) {
 	input := "<s>s</s><code>code</code><i>i</i><u>u</u><mark>mark</mark>"
-	markup, _, err := NewMarkup(input)
+	markup, _, err := NewMarkup(input, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -63,7 +64,7 @@ func TestStyles(t *testing.T) {
 
 func TestSurroundingBlocks(t *testing.T) {
 	input := "<p>first</p>in \t<mark>the</mark> \rmiddle<p>last</p>"
-	markup, _, err := NewMarkup(input)
+	markup, _, err := NewMarkup(input, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -83,7 +84,7 @@ last`
 
 func TestAdjacentBlocks(t *testing.T) {
 	input := "\t<p>first</p>\n\t<p>second</p>"
-	markup, _, err := NewMarkup(input)
+	markup, _, err := NewMarkup(input, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -101,7 +102,7 @@ second`
 
 func TestPoetry(t *testing.T) {
 	input := "he shouted\t\ta few words<br>at those annoying birds<br><br>and that they heard"
-	markup, _, err := NewMarkup(input)
+	markup, _, err := NewMarkup(input, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -121,7 +122,7 @@ and that they heard`
 
 func TestPreservation(t *testing.T) {
 	input := "<pre>multi-space   \n\n\n\n\n far down</pre>"
-	markup, _, err := NewMarkup(input)
+	markup, _, err := NewMarkup(input, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -146,7 +147,7 @@ func TestNestedBlocks(t *testing.T) {
 <p> </p>
 
 <p><img src="https://i.snap.as/P8qpdMbM.jpg" alt=""/></p>`
-	markup, _, err := NewMarkup(input)
+	markup, _, err := NewMarkup(input, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -156,7 +157,7 @@ func TestNestedBlocks(t *testing.T) {
 	}
 	expected := `Once a timid child
 
-` + style.LinkBlock("https://i.snap.as/P8qpdMbM.jpg", 1)
+` + style.LinkBlock("https://i.snap.as/P8qpdMbM.jpg", 1, "https://i.snap.as/P8qpdMbM.jpg")
 	if expected != output {
 		t.Fatalf("excpected output to be %s not %s", expected, output)
 	}
@@ -164,7 +165,7 @@ func TestNestedBlocks(t *testing.T) {
 
 func TestAdjacentLists(t *testing.T) {
 	input := `<ul><li>top list</li></ul><ul><li>bottom list</li></ul>`
-	markup, _, err := NewMarkup(input)
+	markup, _, err := NewMarkup(input, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -181,7 +182,7 @@ func TestAdjacentLists(t *testing.T) {
 
 func TestNestedLists(t *testing.T) {
 	input := `<ul><li>top list<ul><li>nested</li></ul></li></ul>`
-	markup, _, err := NewMarkup(input)
+	markup, _, err := NewMarkup(input, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -198,7 +199,7 @@ func TestNestedLists(t *testing.T) {
 
 func TestBlockInList(t *testing.T) {
 	input := `<ul><li>top list<p><ul><li>paragraph</li></ul></p></li></ul>`
-	markup, _, err := NewMarkup(input)
+	markup, _, err := NewMarkup(input, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -215,7 +216,7 @@ func TestBlockInList(t *testing.T) {
 
 func TestWrapping(t *testing.T) {
 	input := `<p>hello sir</p>`
-	markup, _, err := NewMarkup(input)
+	markup, _, err := NewMarkup(input, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -234,7 +235,7 @@ func TestLinks(t *testing.T) {
 	input := `<a href="https://wikipedia.org">Great site</a>
 <img src="https://example.org" alt="What the heck">
 <iframe title="Music" src="https://spotify.com">`
-	markup, links, err := NewMarkup(input)
+	markup, links, err := NewMarkup(input, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -255,11 +256,260 @@ func TestLinks(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	expected := style.Link("Great site", 1) + "\n\n" +
-		style.LinkBlock("What the heck", 2) + "\n\n" +
-		style.LinkBlock("Music", 3)
+	expected := style.Link("Great site", 1, "https://wikipedia.org") + "\n\n" +
+		style.LinkBlock("What the heck", 2, "https://example.org") + "\n\n" +
+		style.LinkBlock("Music", 3, "https://spotify.com")
 
 	if expected != output {
 		t.Fatalf("excpected output to be %s not %s", expected, output)
 	}
 }
+
+func TestQuoteInline(t *testing.T) {
+	input := `<p>look at this<span class="quote-inline"><br><br>RE: <a href="https://example.org/notes/1">https://example.org/notes/1</a></span></p>`
+	markup, links, err := NewMarkup(input, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(links) != 0 {
+		t.Fatalf("the quote's fallback link should have been hidden, but found %v", links)
+	}
+
+	output := markup.Render(50)
+	expected := "look at this"
+	if expected != output {
+		t.Fatalf("excpected output to be %s not %s", expected, output)
+	}
+}
+
+func TestOffset(t *testing.T) {
+	input := `<a href="https://wikipedia.org">Great site</a>`
+	markup, links, err := NewMarkupWithOffset(input, nil, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(links) != 1 || links[0] != "https://wikipedia.org" {
+		t.Fatalf("the only link should have been https://wikipedia.org, not %v", links)
+	}
+
+	output := markup.Render(50)
+	expected := style.Link("Great site", 3, "https://wikipedia.org")
+	if expected != output {
+		t.Fatalf("excpected output to be %s not %s", expected, output)
+	}
+}
+
+func TestUndrawableImage(t *testing.T) {
+	/* Only images served over https are drawn, so this falls back to its alt text */
+	input := `<img src="http://example.com/cat.png" alt="A cat">`
+	markup, _, err := NewMarkup(input, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output := markup.RenderWithImages(50)
+	expected := style.LinkBlock("A cat", 1, "http://example.com/cat.png")
+	if expected != output {
+		t.Fatalf("excpected output to be %s not %s", expected, output)
+	}
+}
+
+func TestTable(t *testing.T) {
+	input := `<table><tr><th>a</th><th>b</th></tr><tr><td>1</td><td>2</td></tr></table>`
+	markup, _, err := NewMarkup(input, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output := markup.Render(20)
+	bar := style.Color("│")
+	expected := style.Color("┌───┬───┐") + "\n" +
+		bar + " " + style.Bold("a") + " " + bar + " " + style.Bold("b") + " " + bar + "\n" +
+		style.Color("├───┼───┤") + "\n" +
+		bar + " 1 " + bar + " 2 " + bar + "\n" +
+		style.Color("└───┴───┘")
+	if expected != output {
+		t.Fatalf("excpected output to be %s not %s", expected, output)
+	}
+}
+
+func TestStackedTable(t *testing.T) {
+	input := `<table><tr><th>a</th><th>b</th></tr><tr><td>1</td><td>2</td></tr></table>`
+	markup, _, err := NewMarkup(input, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	/* Too narrow for two columns of the minimum width */
+	output := markup.Render(12)
+	expected := style.Bold("a") + ": 1\n" + style.Bold("b") + ": 2"
+	if expected != output {
+		t.Fatalf("excpected output to be %s not %s", expected, output)
+	}
+}
+
+func TestOrderedList(t *testing.T) {
+	input := `<ol start="9" reversed><li>nine</li><li>eight</li></ol>`
+	markup, _, err := NewMarkup(input, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output := markup.Render(50)
+	expected := "9. nine\n8. eight"
+	if expected != output {
+		t.Fatalf("excpected output to be %s not %s", expected, output)
+	}
+}
+
+func TestNestedOrderedList(t *testing.T) {
+	input := `<ol><li>top<ol><li>nested</li></ol></li></ol>`
+	markup, _, err := NewMarkup(input, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output := markup.Render(50)
+	expected := "1. top\n   a. nested"
+	if expected != output {
+		t.Fatalf("excpected output to be %s not %s", expected, output)
+	}
+}
+
+func TestScripts(t *testing.T) {
+	input := `x<sup>2</sup> H<sub>2</sub>O e<sup>iπ</sup>`
+	markup, _, err := NewMarkup(input, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output := markup.Render(50)
+	expected := "x² H₂O e^iπ"
+	if expected != output {
+		t.Fatalf("excpected output to be %s not %s", expected, output)
+	}
+}
+
+func TestDefinitionList(t *testing.T) {
+	input := `<dl><dt>term</dt><dd>meaning</dd></dl>`
+	markup, _, err := NewMarkup(input, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output := markup.Render(50)
+	expected := style.Bold("term") + "\n  meaning"
+	if expected != output {
+		t.Fatalf("excpected output to be %s not %s", expected, output)
+	}
+}
+
+func TestDetails(t *testing.T) {
+	input := `<details><summary>spoiler</summary><p><a href="https://example.com">hidden</a></p></details><a href="https://example.org">after</a>`
+	markup, links, err := NewMarkup(input, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	/* The hidden link still takes its number */
+	if len(links) != 2 {
+		t.Fatalf("expected 2 links not %d", len(links))
+	}
+	output := markup.Render(50)
+	expected := style.Color("▸ ") + "spoiler\n\n" + style.Link("after", 2, "https://example.org")
+	if expected != output {
+		t.Fatalf("excpected output to be %s not %s", expected, output)
+	}
+
+	if !markup.ToggleDetails() {
+		t.Fatal("expected the details to be found")
+	}
+	output = markup.Render(50)
+	expected = style.Color("▾ ") + "spoiler\n  " + style.Link("hidden", 1, "https://example.com") + "\n\n" + style.Link("after", 2, "https://example.org")
+	if expected != output {
+		t.Fatalf("excpected output to be %s not %s", expected, output)
+	}
+}
+
+func TestHighlightedCode(t *testing.T) {
+	input := `<pre><code class="language-go">return nil</code></pre>`
+	markup, _, err := NewMarkup(input, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output := markup.Render(10)
+	expected := style.CodeBlock(ansi.Pad(style.Syntax("return nil", "go"), 10))
+	if expected != output {
+		t.Fatalf("excpected output to be %s not %s", expected, output)
+	}
+	if expected == style.CodeBlock(ansi.Pad("return nil", 10)) {
+		t.Fatal("expected the code to be highlighted")
+	}
+}
+
+func TestLinkKinds(t *testing.T) {
+	input := `<span class="h-card"><a href="https://example.org/@alice" class="u-url mention">@<span>alice</span></a></span> <a href="https://example.org/tags/go" class="mention hashtag" rel="tag">#<span>go</span></a> <a href="https://example.com">site</a>`
+	markup, links, err := NewMarkup(input, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(links) != 3 {
+		t.Fatalf("expected 3 links not %d", len(links))
+	}
+	kinds := markup.LinkKinds()
+	if kinds[0] != Mention || kinds[1] != Hashtag || kinds[2] != External {
+		t.Fatalf("expected a mention, a hashtag and an external link, not %v", kinds)
+	}
+	if !markup.IsMentionOrHashtag(0) || !markup.IsMentionOrHashtag(1) || markup.IsMentionOrHashtag(2) || markup.IsMentionOrHashtag(3) {
+		t.Fatal("expected only the first two links to be a mention or hashtag")
+	}
+
+	output := markup.Render(50)
+	expected := style.Mention("@alice", 1, "https://example.org/@alice") + " " +
+		style.Mention("#go", 2, "https://example.org/tags/go") + " " +
+		style.Link("site", 3, "https://example.com")
+	if expected != output {
+		t.Fatalf("excpected output to be %s not %s", expected, output)
+	}
+}
+
+func TestShortenedLink(t *testing.T) {
+	input := `<a href="https://example.com/a/long/path"><span class="invisible">https://</span><span class="ellipsis">example.com/a/lo</span><span class="invisible">ng/path</span></a>`
+	markup, _, err := NewMarkup(input, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output := markup.Render(50)
+	expected := style.Link("example.com/a/lo…", 1, "https://example.com/a/long/path")
+	if expected != output {
+		t.Fatalf("excpected output to be %s not %s", expected, output)
+	}
+}
+
+func TestRelativeLinks(t *testing.T) {
+	base, err := url.Parse("https://example.org/posts/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := `<a href="/about">about</a> <a href="javascript:alert(1)">trap</a> <img src="cat.png" alt="cat">`
+	markup, links, err := NewMarkup(input, base)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(links) != 2 || links[0] != "https://example.org/about" || links[1] != "https://example.org/posts/cat.png" {
+		t.Fatalf("expected the relative links to be resolved and the unsafe one dropped, not %v", links)
+	}
+
+	output := markup.Render(50)
+	expected := style.Link("about", 1, "https://example.org/about") + " trap\n\n" +
+		style.LinkBlock("cat", 2, "https://example.org/posts/cat.png")
+	if expected != output {
+		t.Fatalf("excpected output to be %s not %s", expected, output)
+	}
+}
@@ -0,0 +1,201 @@
+package hypertext
+
+import (
+	"golang.org/x/net/html"
+	"servitor/ansi"
+	"servitor/style"
+	"strings"
+)
+
+/* Columns narrower than this are unreadable, so the table is stacked instead */
+const minColumnWidth = 6
+
+type cell struct {
+	text   string
+	header bool
+}
+
+/* Lays out a table with borders, or one cell per line if it is too wide to fit */
+func renderTable(node *html.Node, ctx context) string {
+	caption := ""
+	rows := [][]cell{}
+	var visit func(n *html.Node)
+	visit = func(n *html.Node) {
+		for current := n.FirstChild; current != nil; current = current.NextSibling {
+			if current.Type != html.ElementNode {
+				continue
+			}
+			switch current.Data {
+			case "caption":
+				caption = strings.Trim(renderChildren(current, ctx), " \n")
+			case "thead", "tbody", "tfoot":
+				visit(current)
+			case "tr":
+				rows = append(rows, renderRow(current, n.Data == "thead", ctx))
+			}
+		}
+	}
+	visit(node)
+
+	columns := 0
+	for _, row := range rows {
+		if len(row) > columns {
+			columns = len(row)
+		}
+	}
+	if columns == 0 {
+		return block(caption)
+	}
+	for i := range rows {
+		for len(rows[i]) < columns {
+			rows[i] = append(rows[i], cell{})
+		}
+	}
+
+	/* Each column has a space on either side and a border on its right, plus the border on the left */
+	available := ctx.width - 3*columns - 1
+	var output string
+	if available < columns*minColumnWidth {
+		output = stackedTable(rows, ctx.width)
+	} else {
+		output = griddedTable(rows, columnWidths(rows, available))
+	}
+	if caption != "" {
+		output = ansi.Wrap(style.Italic(caption), ctx.width) + "\n" + output
+	}
+	return block(output)
+}
+
+func renderRow(node *html.Node, inHead bool, ctx context) []cell {
+	row := []cell{}
+	for current := node.FirstChild; current != nil; current = current.NextSibling {
+		if current.Type != html.ElementNode || (current.Data != "td" && current.Data != "th") {
+			continue
+		}
+		text := strings.Trim(renderChildren(current, ctx), " \n")
+		header := inHead || current.Data == "th"
+		if header {
+			text = style.Bold(text)
+		}
+		row = append(row, cell{text, header})
+	}
+	return row
+}
+
+/*
+Gives each column its natural width if they all fit, otherwise shares the
+space out evenly, with columns narrower than their share giving up the rest
+*/
+func columnWidths(rows [][]cell, available int) []int {
+	columns := len(rows[0])
+	natural := make([]int, columns)
+	for _, row := range rows {
+		for i, c := range row {
+			if width := ansi.Width(c.text); width > natural[i] {
+				natural[i] = width
+			}
+		}
+	}
+
+	widths := make([]int, columns)
+	settled := make([]bool, columns)
+	remaining, unsettled := available, columns
+	for changed := true; changed && unsettled > 0; {
+		changed = false
+		share := remaining / unsettled
+		for i := range widths {
+			if !settled[i] && natural[i] <= share {
+				widths[i] = natural[i]
+				settled[i] = true
+				remaining -= natural[i]
+				unsettled -= 1
+				changed = true
+			}
+		}
+	}
+	for i := range widths {
+		if settled[i] {
+			continue
+		}
+		widths[i] = remaining / unsettled
+		remaining -= widths[i]
+		unsettled -= 1
+	}
+	for i := range widths {
+		if widths[i] < 1 {
+			widths[i] = 1
+		}
+	}
+	return widths
+}
+
+func griddedTable(rows [][]cell, widths []int) string {
+	border := func(left, middle, right string) string {
+		segments := make([]string, len(widths))
+		for i, width := range widths {
+			segments[i] = strings.Repeat("─", width+2)
+		}
+		return style.Color(left + strings.Join(segments, middle) + right)
+	}
+
+	lines := []string{border("┌", "┬", "┐")}
+	for r, row := range rows {
+		wrapped := make([][]string, len(row))
+		height := 0
+		for i, c := range row {
+			wrapped[i] = strings.Split(ansi.Pad(ansi.Wrap(c.text, widths[i]), widths[i]), "\n")
+			if len(wrapped[i]) > height {
+				height = len(wrapped[i])
+			}
+		}
+		for j := 0; j < height; j++ {
+			line := style.Color("│")
+			for i := range row {
+				if j < len(wrapped[i]) {
+					line += " " + wrapped[i][j] + " "
+				} else {
+					line += strings.Repeat(" ", widths[i]+2)
+				}
+				line += style.Color("│")
+			}
+			lines = append(lines, line)
+		}
+		/* Separate the header from the body */
+		if r != len(rows)-1 && isHeader(row) && !isHeader(rows[r+1]) {
+			lines = append(lines, border("├", "┼", "┤"))
+		}
+	}
+	lines = append(lines, border("└", "┴", "┘"))
+	return strings.Join(lines, "\n")
+}
+
+func isHeader(row []cell) bool {
+	for _, c := range row {
+		if c.text != "" && !c.header {
+			return false
+		}
+	}
+	return true
+}
+
+/* Lists each row's cells beneath one another, labelled with the header of their column */
+func stackedTable(rows [][]cell, width int) string {
+	var headers []cell
+	if len(rows) > 1 && isHeader(rows[0]) {
+		headers, rows = rows[0], rows[1:]
+	}
+
+	entries := []string{}
+	for _, row := range rows {
+		entry := []string{}
+		for i, c := range row {
+			text := c.text
+			if headers != nil && headers[i].text != "" {
+				text = headers[i].text + ": " + text
+			}
+			entry = append(entry, ansi.Wrap(text, width))
+		}
+		entries = append(entries, strings.Join(entry, "\n"))
+	}
+	return strings.Join(entries, "\n"+style.Color(strings.Repeat("─", width))+"\n")
+}
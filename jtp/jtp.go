@@ -147,6 +147,16 @@ func Get(link *url.URL, accept string, tolerated []string, maxRedirects uint) (m
 	return dictionary, link, nil
 }
 
+/* Removes the response for the link from the cache, including a cached error */
+func Evict(link *url.URL) {
+	cache.Remove(link.String())
+}
+
+/* Reports whether a response for the link is in the cache */
+func Cached(link *url.URL) bool {
+	return cache.Contains(link.String())
+}
+
 func parseStatusLine(text string) (string, error) {
 	matches := statusLineRegexp.FindStringSubmatch(text)
 
@@ -78,6 +78,7 @@ func help() {
 Commands:
 servitor open <url or @>
 servitor feed <feed name>
+servitor migrate <feed name>
 
 Keybindings:
   Navigation:
@@ -86,20 +87,50 @@ Keybindings:
   space - select the highlighted item
   c - view the creator of the highlighted item
   r - view the recipient of the highlighted item (e.g. the group it was posted to)
+  d - view everyone the highlighted post is addressed to
   a - view the actor of the activity (e.g. view the retweeter of a retweet)
   h - move back in your browser history
   l - move forward in your browser history
   g - move to the expanded item (i.e. move to the current OP)
+  G - read the list from its other end (e.g. jump to an actor's oldest posts)
+  q - view the post quoted by the highlighted post
+  v - view the likes of the highlighted post
+  s - view the shares of the highlighted post
+  R - retry loading the highlighted item if it failed to load
+  E - fetch the highlighted post again and show how it was edited
+  x - expand or collapse the hidden details in the highlighted post
+  S - switch between the highlighted post's HTML and its source text
+  i - inspect the raw object behind the highlighted item
   ctrl+c - exit the program
 
+  Actors:
+  P - view the highlighted actor's posts, with pinned posts first
+  F - view the highlighted actor's followers
+  W - view who the highlighted actor is following
+  N - view the highlighted actor's pinned posts
+  T - view the highlighted actor's featured hashtags
+  M - view the account the highlighted actor moved to
+
+  Threads:
+  t - toggle between the flat and threaded views of the replies to the current OP
+  z - collapse or expand the replies to the highlighted comment
+  U - move to the comment the highlighted comment replies to
+  J - move to the next reply to the same comment
+  K - move to the previous reply to the same comment
+
   Media:
   p - open the highlighted user's profile picture
   b - open the highlighted user's banner
   o - open the content of a post itself (e.g. open the video associated with a video post)
-  number keys - open a link within the highlighted text
+  O - list every stream and subtitle track of a post, to open one other than the default
+  I - draw or hide the images of the highlighted item
+  number keys - select a link within the highlighted text, then press enter to open it
+    (mentions and hashtags open in servitor, other links externally) or . to open it in servitor
 
   Commands:
   :open <url or @>
   :feed <feed name>
+  :migrate <feed name>
+  :seek <date> - move to the first item from a date, e.g. 2020-01-31
 `)
 }
@@ -4,16 +4,17 @@ import (
 	"bytes"
 	"github.com/yuin/goldmark"
 	"github.com/yuin/goldmark/extension"
+	"net/url"
 	"servitor/hypertext"
 )
 
 var renderer = goldmark.New(goldmark.WithExtensions(extension.GFM))
 
-func NewMarkup(text string) (*hypertext.Markup, []string, error) {
+func NewMarkup(text string, base *url.URL) (*hypertext.Markup, []string, error) {
 	var buf bytes.Buffer
 	if err := renderer.Convert([]byte(text), &buf); err != nil {
 		return nil, []string{}, err
 	}
 	output := buf.String()
-	return hypertext.NewMarkup(output)
+	return hypertext.NewMarkup(output, base)
 }
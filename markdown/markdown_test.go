@@ -12,7 +12,7 @@ func TestBasic(t *testing.T) {
 
 * Nested list
   * Nesting`
-	markup, links, err := NewMarkup(input)
+	markup, links, err := NewMarkup(input, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -26,8 +26,8 @@ func TestBasic(t *testing.T) {
 	}
 
 	output := markup.Render(50)
-	expected := style.Link("Here's a link!", 1) + "\n\n" +
-		style.LinkBlock("This is a beautiful image!", 2) + "\n\n" +
+	expected := style.Link("Here's a link!", 1, "https://wikipedia.org") + "\n\n" +
+		style.LinkBlock("This is a beautiful image!", 2, "https://miro.medium.com/v2/resize:fit:900/0*L31Zh4YhAv3Wokco") + "\n\n" +
 		style.Bullet("Nested list\n"+style.Bullet("Nesting"))
 
 	if expected != output {
@@ -0,0 +1,356 @@
+package mfm
+
+import (
+	"net/url"
+	"regexp"
+	"servitor/ansi"
+	"servitor/href"
+	"servitor/hypertext"
+	"servitor/style"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+/*
+	Misskey Flavored Markdown, used by Misskey and its forks:
+	https://misskey-hub.net/en/docs/for-users/features/mfm/
+
+	It is closer to a set of inline decorations than to Markdown:
+	line breaks are kept as they are, and besides code blocks, math
+	blocks and quotes, everything is inline.
+*/
+
+type Markup struct {
+	text string
+	/* Where the markup came from, which local mentions, hashtags and relative links are resolved against */
+	base        *url.URL
+	cached      string
+	cachedWidth int
+	kinds       []hypertext.LinkKind
+}
+
+type context struct {
+	base  *url.URL
+	links *[]string
+	kinds *[]hypertext.LinkKind
+}
+
+/* Links are resolved against `base`, which may be nil */
+func NewMarkup(text string, base *url.URL) (*Markup, []string, error) {
+	m := &Markup{
+		text: text,
+		base: base,
+	}
+	rendered, links, kinds := m.renderWithLinks(80)
+	m.cached = rendered
+	m.cachedWidth = 80
+	m.kinds = kinds
+	return m, links, nil
+}
+
+func (m *Markup) Render(width int) string {
+	if m.cachedWidth == width {
+		return m.cached
+	}
+	rendered, _, _ := m.renderWithLinks(width)
+	m.cached = rendered
+	m.cachedWidth = width
+	return rendered
+}
+
+/* Whether each link is a mention, hashtag or external link, in the order of the links */
+func (m *Markup) LinkKinds() []hypertext.LinkKind {
+	return m.kinds
+}
+
+/* Whether the link at the index leads to a mention or hashtag rather than the web */
+func (m *Markup) IsMentionOrHashtag(index int) bool {
+	return index >= 0 && index < len(m.kinds) && m.kinds[index] != hypertext.External
+}
+
+func (m *Markup) renderWithLinks(width int) (string, []string, []hypertext.LinkKind) {
+	ctx := context{
+		base:  m.base,
+		links: &[]string{},
+		kinds: &[]hypertext.LinkKind{},
+	}
+	output := renderBlocks(strings.Split(m.text, "\n"), ctx, width)
+	return strings.Trim(output, "\n"), *ctx.links, *ctx.kinds
+}
+
+func (ctx context) register(link string, kind hypertext.LinkKind) int {
+	*ctx.links = append(*ctx.links, link)
+	*ctx.kinds = append(*ctx.kinds, kind)
+	return len(*ctx.links)
+}
+
+func renderBlocks(lines []string, ctx context, width int) string {
+	output := ""
+	paragraph := []string{}
+	flush := func() {
+		if len(paragraph) == 0 {
+			return
+		}
+		output += ansi.Wrap(renderInline(strings.Join(paragraph, "\n"), ctx), width) + "\n"
+		paragraph = []string{}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		switch {
+		case strings.HasPrefix(line, "```"):
+			flush()
+			language := strings.TrimSpace(strings.TrimPrefix(line, "```"))
+			code := []string{}
+			/* Like gemtext, an unclosed code block runs to the end */
+			for i += 1; i < len(lines) && strings.TrimSpace(lines[i]) != "```"; i++ {
+				code = append(code, lines[i])
+			}
+			contents := style.Syntax(strings.Join(code, "\n"), language)
+			output += style.CodeBlock(ansi.Pad(ansi.DumbWrap(contents, width), width)) + "\n"
+		case strings.HasPrefix(strings.TrimSpace(line), `\[`):
+			flush()
+			math := strings.TrimPrefix(strings.TrimSpace(line), `\[`)
+			for !strings.HasSuffix(strings.TrimSpace(math), `\]`) && i+1 < len(lines) {
+				i += 1
+				math += "\n" + lines[i]
+			}
+			math = strings.Trim(strings.TrimSuffix(strings.TrimSpace(math), `\]`), " \n")
+			output += style.CodeBlock(ansi.Pad(ansi.DumbWrap(math, width), width)) + "\n"
+		case strings.HasPrefix(line, ">"):
+			flush()
+			quoted := []string{}
+			for ; i < len(lines) && strings.HasPrefix(lines[i], ">"); i++ {
+				quoted = append(quoted, strings.TrimPrefix(strings.TrimPrefix(lines[i], ">"), " "))
+			}
+			i -= 1
+			inner := strings.Trim(renderBlocks(quoted, ctx, width-1), "\n")
+			output += style.QuoteBlock(inner) + "\n"
+		default:
+			paragraph = append(paragraph, line)
+		}
+	}
+	flush()
+	return output
+}
+
+var (
+	urlPattern     = regexp.MustCompile(`^https?://[A-Za-z0-9.?#/@:%_~!$&'()*+,;=\[\]\-]+`)
+	linkPattern    = regexp.MustCompile(`^\??\[([^\]\n]+)\]\(<?([^)>\s]+)>?\)`)
+	emojiPattern   = regexp.MustCompile(`^:[A-Za-z0-9_+\-]+:`)
+	mentionPattern = regexp.MustCompile(`^@([A-Za-z0-9_]+(?:[.\-]+[A-Za-z0-9_]+)*)(?:@([A-Za-z0-9\-]+(?:\.[A-Za-z0-9\-]+)*))?`)
+	/* Italics only apply to letters, numbers and spaces, so that underscores in names are left alone */
+	italicPattern = regexp.MustCompile(`^(\*|_)([\p{L}\p{N} \t]+)(\*|_)`)
+	tagPattern    = regexp.MustCompile(`^<(b|i|s|small|center|plain)>`)
+)
+
+/* Characters that end a hashtag, besides whitespace */
+const hashtagTerminators = ".,!?'\"#:/[]【】()「」<>"
+
+func renderInline(text string, ctx context) string {
+	output := ""
+	previous := ' '
+	for position := 0; position < len(text); {
+		rest := text[position:]
+		rendered, length := renderConstruct(rest, previous, ctx)
+		if length == 0 {
+			_, length = utf8.DecodeRuneInString(rest)
+			rendered = rest[:length]
+		}
+		output += rendered
+		previous, _ = utf8.DecodeLastRuneInString(rest[:length])
+		position += length
+	}
+	return output
+}
+
+/* Renders the construct at the start of `text`, returning how much of it was consumed, which is 0 if there is none */
+func renderConstruct(text string, previous rune, ctx context) (string, int) {
+	/* Mentions, hashtags and italics only begin at the start of a word */
+	wordStart := !unicode.IsLetter(previous) && !unicode.IsDigit(previous)
+
+	switch {
+	case strings.HasPrefix(text, "`"):
+		end := strings.IndexAny(text[1:], "`\n")
+		if end > 0 && text[1+end] == '`' {
+			return style.Code(text[1 : 1+end]), end + 2
+		}
+	case strings.HasPrefix(text, `\(`):
+		if end := strings.Index(text, `\)`); end != -1 && !strings.Contains(text[:end], "\n") {
+			return style.Code(strings.TrimSpace(text[2:end])), end + 2
+		}
+	case strings.HasPrefix(text, "$["):
+		return function(text, ctx)
+	case strings.HasPrefix(text, "***"), strings.HasPrefix(text, "**"), strings.HasPrefix(text, "__"), strings.HasPrefix(text, "~~"):
+		delimiter := text[:2]
+		if strings.HasPrefix(text, "***") {
+			delimiter = "***"
+		}
+		end := strings.Index(text[len(delimiter):], delimiter)
+		if end <= 0 {
+			break
+		}
+		inner := renderInline(text[len(delimiter):len(delimiter)+end], ctx)
+		length := 2*len(delimiter) + end
+		if delimiter == "~~" {
+			return style.Strikethrough(inner), length
+		}
+		return style.Bold(inner), length
+	case strings.HasPrefix(text, "<"):
+		if match := tagPattern.FindStringSubmatch(text); match != nil {
+			closing := "</" + match[1] + ">"
+			end := strings.Index(text, closing)
+			if end == -1 {
+				break
+			}
+			contents := text[len(match[0]):end]
+			length := end + len(closing)
+			switch match[1] {
+			case "b":
+				return style.Bold(renderInline(contents, ctx)), length
+			case "i":
+				return style.Italic(renderInline(contents, ctx)), length
+			case "s":
+				return style.Strikethrough(renderInline(contents, ctx)), length
+			case "plain":
+				return contents, length
+			default:
+				/* Small and centered text can't be shown as such */
+				return renderInline(strings.Trim(contents, "\n"), ctx), length
+			}
+		}
+		/* Links in angle brackets, which may contain characters bare links can't */
+		if end := strings.IndexAny(text, ">\n"); end != -1 && text[end] == '>' && urlPattern.MatchString(text[1:end]) {
+			return link(text[1:end], text[1:end], ctx), end + 1
+		}
+	case strings.HasPrefix(text, "["), strings.HasPrefix(text, "?["):
+		if match := linkPattern.FindStringSubmatch(text); match != nil {
+			return link(renderInline(match[1], ctx), match[2], ctx), len(match[0])
+		}
+	case strings.HasPrefix(text, "http"):
+		if match := urlPattern.FindString(text); match != "" {
+			match = trimURL(match)
+			return link(match, match, ctx), len(match)
+		}
+	case strings.HasPrefix(text, ":"):
+		/* Custom emoji can't be drawn inline, so their names are kept as they are */
+		if match := emojiPattern.FindString(text); match != "" {
+			return match, len(match)
+		}
+	case strings.HasPrefix(text, "@") && wordStart:
+		if match := mentionPattern.FindStringSubmatch(text); match != nil {
+			return mention(match[0], match[1], match[2], ctx), len(match[0])
+		}
+	case strings.HasPrefix(text, "#") && wordStart:
+		end := strings.IndexFunc(text[1:], func(r rune) bool {
+			return unicode.IsSpace(r) || strings.ContainsRune(hashtagTerminators, r)
+		})
+		if end == -1 {
+			end = len(text) - 1
+		}
+		if end > 0 {
+			return hashtag(text[:end+1], text[1:end+1], ctx), end + 1
+		}
+	case (strings.HasPrefix(text, "*") || strings.HasPrefix(text, "_")) && wordStart:
+		if match := italicPattern.FindStringSubmatch(text); match != nil && match[1] == match[3] {
+			return style.Italic(renderInline(match[2], ctx)), len(match[0])
+		}
+	}
+	return "", 0
+}
+
+/* Trailing periods and commas end sentences rather than links, as does a closing parenthesis without an opening one */
+func trimURL(link string) string {
+	for {
+		switch {
+		case strings.HasSuffix(link, "."), strings.HasSuffix(link, ","):
+			link = link[:len(link)-1]
+		case strings.HasSuffix(link, ")") && strings.Count(link, "(") < strings.Count(link, ")"):
+			link = link[:len(link)-1]
+		default:
+			return link
+		}
+	}
+}
+
+/* Unsafe links are shown as their text */
+func link(text string, reference string, ctx context) string {
+	resolved, err := href.Resolve(ctx.base, reference)
+	if err != nil {
+		return text
+	}
+	return style.Link(text, ctx.register(resolved, hypertext.External), resolved)
+}
+
+/* Mentions without a host are local to the server the markup came from */
+func mention(text string, username string, host string, ctx context) string {
+	if host == "" {
+		if ctx.base == nil {
+			return style.Bold(text)
+		}
+		host = ctx.base.Host
+	}
+	link := (&url.URL{Scheme: "https", Host: host, Path: "/@" + username}).String()
+	return style.Mention(text, ctx.register(link, hypertext.Mention), link)
+}
+
+func hashtag(text string, name string, ctx context) string {
+	if ctx.base == nil {
+		return style.Bold(text)
+	}
+	link := (&url.URL{Scheme: ctx.base.Scheme, Host: ctx.base.Host, Path: "/tags/" + name}).String()
+	return style.Mention(text, ctx.register(link, hypertext.Hashtag), link)
+}
+
+/*
+Renders a function like $[x2 text] or $[fg.color=f00 text]. Animations,
+fonts, colors and transformations can't be shown in a terminal, so most
+functions are reduced to their contents; the larger sizes are emboldened
+*/
+func function(text string, ctx context) (string, int) {
+	depth := 0
+	end := -1
+	for i := 2; i < len(text) && end == -1; i++ {
+		switch text[i] {
+		case '[':
+			depth += 1
+		case ']':
+			if depth == 0 {
+				end = i
+			}
+			depth -= 1
+		}
+	}
+	if end == -1 {
+		return "", 0
+	}
+
+	name, contents, _ := strings.Cut(text[2:end], " ")
+	if name == "" {
+		return "", 0
+	}
+	name, _, _ = strings.Cut(name, ".")
+	length := end + 1
+
+	switch name {
+	case "x2", "x3", "x4", "tada":
+		return style.Bold(renderInline(contents, ctx)), length
+	case "ruby":
+		base, annotation, found := strings.Cut(strings.TrimSpace(contents), " ")
+		if !found {
+			return renderInline(base, ctx), length
+		}
+		return renderInline(base, ctx) + "(" + strings.TrimSpace(annotation) + ")", length
+	case "unixtime":
+		seconds, err := strconv.ParseInt(strings.TrimSpace(contents), 10, 64)
+		if err != nil {
+			return renderInline(contents, ctx), length
+		}
+		return style.Color(time.Unix(seconds, 0).Format("2 Jan 2006 15:04")), length
+	default:
+		return renderInline(contents, ctx), length
+	}
+}
@@ -0,0 +1,102 @@
+package mfm
+
+import (
+	"net/url"
+	"servitor/hypertext"
+	"servitor/style"
+	"testing"
+	"time"
+)
+
+func TestBasic(t *testing.T) {
+	base, err := url.Parse("https://misskey.example/notes/9k2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := "hi @alice@example.org and @bob, **bold** <i>italic</i> ~~gone~~ `code` :blob_cat: #mfm\n" +
+		"[label](https://example.com/page) https://example.com/bare. <https://example.com/angled>"
+	markup, links, err := NewMarkup(input, base)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedLinks := []string{
+		"https://example.org/@alice",
+		"https://misskey.example/@bob",
+		"https://misskey.example/tags/mfm",
+		"https://example.com/page",
+		"https://example.com/bare",
+		"https://example.com/angled",
+	}
+	if len(links) != len(expectedLinks) {
+		t.Fatalf("expected links %v not %v", expectedLinks, links)
+	}
+	for i, link := range expectedLinks {
+		if links[i] != link {
+			t.Fatalf("expected link %d to be %s not %s", i+1, link, links[i])
+		}
+	}
+
+	kinds := markup.LinkKinds()
+	if kinds[0] != hypertext.Mention || kinds[1] != hypertext.Mention || kinds[2] != hypertext.Hashtag || kinds[3] != hypertext.External {
+		t.Fatalf("expected two mentions, a hashtag and external links, not %v", kinds)
+	}
+
+	output := markup.Render(200)
+	expected := "hi " + style.Mention("@alice@example.org", 1, expectedLinks[0]) +
+		" and " + style.Mention("@bob", 2, expectedLinks[1]) + ", " +
+		style.Bold("bold") + " " + style.Italic("italic") + " " + style.Strikethrough("gone") + " " +
+		style.Code("code") + " :blob_cat: " + style.Mention("#mfm", 3, expectedLinks[2]) + "\n" +
+		style.Link("label", 4, expectedLinks[3]) + " " + style.Link("https://example.com/bare", 5, expectedLinks[4]) + ". " +
+		style.Link("https://example.com/angled", 6, expectedLinks[5])
+	if expected != output {
+		t.Fatalf("expected %s not %s", expected, output)
+	}
+}
+
+func TestFunctions(t *testing.T) {
+	input := "$[x2 $[spin **big**]] $[fg.color=f00 red] $[ruby 本 ほん] $[unixtime 1700000000] $[unclosed"
+	markup, _, err := NewMarkup(input, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output := markup.Render(200)
+	expected := style.Bold(style.Bold("big")) + " red 本(ほん) " +
+		style.Color(time.Unix(1700000000, 0).Format("2 Jan 2006 15:04")) + " $[unclosed"
+	if expected != output {
+		t.Fatalf("expected %s not %s", expected, output)
+	}
+}
+
+func TestBlocks(t *testing.T) {
+	input := "> quoted\n> *lines*\nbetween\n```go\nreturn nil\n```\n\\[x^2\\]"
+	markup, _, err := NewMarkup(input, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output := markup.Render(20)
+	expected := style.QuoteBlock("quoted\n"+style.Italic("lines")) + "\n" +
+		"between\n" +
+		style.CodeBlock(style.Syntax("return nil", "go")+"          ") + "\n" +
+		style.CodeBlock("x^2                 ")
+	if expected != output {
+		t.Fatalf("expected %q not %q", expected, output)
+	}
+}
+
+func TestUnsafeLinks(t *testing.T) {
+	markup, links, err := NewMarkup("[click](javascript:void) user@example.org snake_case_name", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(links) != 0 {
+		t.Fatalf("expected no links, not %v", links)
+	}
+	output := markup.Render(200)
+	expected := "click user@example.org snake_case_name"
+	if expected != output {
+		t.Fatalf("expected %s not %s", expected, output)
+	}
+}
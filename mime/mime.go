@@ -32,6 +32,20 @@ func Unknown() *MediaType {
 	}
 }
 
+/* The media type of links to ActivityPub objects, like mentions */
+func ActivityPub() *MediaType {
+	return &MediaType{
+		Essence:   "application/activity+json",
+		Supertype: "application",
+		Subtype:   "activity+json",
+	}
+}
+
+/* Whether the media type is served by ActivityPub, so links of this type are opened within servitor */
+func (m *MediaType) IsActivityPub() bool {
+	return m.Matches([]string{"application/activity+json", "application/ld+json"})
+}
+
 func UnknownSubtype(supertype string) *MediaType {
 	return &MediaType{
 		Essence:   supertype + "/*",
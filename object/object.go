@@ -8,9 +8,13 @@ import (
 	"servitor/gemtext"
 	"servitor/hypertext"
 	"servitor/markdown"
+	"servitor/mfm"
 	"servitor/mime"
 	"servitor/plaintext"
 	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -85,6 +89,35 @@ func (o Object) GetTime(key string) (time.Time, error) {
 	}
 }
 
+/*
+Parses the subset of ISO 8601 durations that ActivityPub software uses,
+e.g. PeerTube's "PT1H2M3S"; see https://www.w3.org/TR/xmlschema11-2/#duration
+*/
+var durationRegexp = regexp.MustCompile(`^P(?:([0-9]+)D)?(?:T(?:([0-9]+)H)?(?:([0-9]+)M)?(?:([0-9]+(?:\.[0-9]+)?)S)?)?$`)
+
+func (o Object) GetDuration(key string) (time.Duration, error) {
+	value, err := o.GetString(key)
+	if err != nil {
+		return 0, err
+	}
+	matches := durationRegexp.FindStringSubmatch(value)
+	if matches == nil || value == "P" || strings.HasSuffix(value, "T") {
+		return 0, fmt.Errorf("failed to parse duration \"%s\": %s is not an ISO 8601 duration", key, value)
+	}
+	var duration time.Duration
+	for i, unit := range []time.Duration{24 * time.Hour, time.Hour, time.Minute, time.Second} {
+		if matches[i+1] == "" {
+			continue
+		}
+		amount, err := strconv.ParseFloat(matches[i+1], 64)
+		if err != nil {
+			return 0, fmt.Errorf("failed to parse duration \"%s\": %w", key, err)
+		}
+		duration += time.Duration(amount * float64(unit))
+	}
+	return duration, nil
+}
+
 func (o Object) GetURL(key string) (*url.URL, error) {
 	if value, err := o.GetString(key); err != nil {
 		return nil, err
@@ -113,7 +146,8 @@ type Markup interface {
 	Render(width int) string
 }
 
-func (o Object) GetMarkup(contentKey string, mediaTypeKey string) (Markup, []string, error) {
+/* The links in the markup are resolved against `base`, the id of the object it belongs to */
+func (o Object) GetMarkup(contentKey string, mediaTypeKey string, base *url.URL) (Markup, []string, error) {
 	content, err := o.GetString(contentKey)
 	if err != nil {
 		return nil, nil, err
@@ -127,13 +161,15 @@ func (o Object) GetMarkup(contentKey string, mediaTypeKey string) (Markup, []str
 
 	switch mediaType.Essence {
 	case "text/plain":
-		return plaintext.NewMarkup(content)
+		return plaintext.NewMarkup(content, base)
 	case "text/html":
-		return hypertext.NewMarkup(content)
+		return hypertext.NewMarkup(content, base)
 	case "text/gemini":
-		return gemtext.NewMarkup(content)
+		return gemtext.NewMarkup(content, base)
 	case "text/markdown":
-		return markdown.NewMarkup(content)
+		return markdown.NewMarkup(content, base)
+	case "text/x.misskey.markdown":
+		return mfm.NewMarkup(content, base)
 	default:
 		return nil, nil, errors.New("cannot render text of mime type " + mediaType.Essence)
 	}
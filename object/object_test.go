@@ -3,6 +3,7 @@ package object
 import (
 	"errors"
 	"testing"
+	"time"
 )
 
 func TestString(t *testing.T) {
@@ -107,3 +108,41 @@ func TestList(t *testing.T) {
 		t.Fatalf(`Expected ErrKeyNotPresent, not %v`, err)
 	}
 }
+
+func TestDuration(t *testing.T) {
+	o := Object{
+		"seconds": "PT95S",
+		"full":    "P1DT2H3M4.5S",
+		"bad":     "95 seconds",
+		"empty":   "PT",
+		// deliberately absent: "absent": "value",
+	}
+	duration, err := o.GetDuration("seconds")
+	if err != nil {
+		t.Fatalf("Problem extracting duration: %v", err)
+	}
+	if duration != 95*time.Second {
+		t.Fatalf(`Expected 1m35s not %v`, duration)
+	}
+
+	duration, err = o.GetDuration("full")
+	if err != nil {
+		t.Fatalf("Problem extracting duration: %v", err)
+	}
+	if expected := 26*time.Hour + 3*time.Minute + 4500*time.Millisecond; duration != expected {
+		t.Fatalf(`Expected %v not %v`, expected, duration)
+	}
+
+	if _, err = o.GetDuration("bad"); err == nil {
+		t.Fatalf("Expected an error for an invalid duration")
+	}
+
+	if _, err = o.GetDuration("empty"); err == nil {
+		t.Fatalf("Expected an error for an empty duration")
+	}
+
+	_, err = o.GetDuration("absent")
+	if !errors.Is(err, ErrKeyNotPresent) {
+		t.Fatalf(`Expected ErrKeyNotPresent, not %v`, err)
+	}
+}
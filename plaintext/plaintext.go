@@ -1,7 +1,9 @@
 package plaintext
 
 import (
+	"net/url"
 	"servitor/ansi"
+	"servitor/href"
 	"servitor/style"
 	"regexp"
 	"strings"
@@ -9,15 +11,18 @@ import (
 
 type Markup struct {
 	text        string
+	base        *url.URL
 	cached      string
 	cachedWidth int
 }
 
-func NewMarkup(text string) (*Markup, []string, error) {
-	rendered, links := renderWithLinks(text, 80)
+/* Only absolute links are recognized in plain text, but `base` is taken like the other markups */
+func NewMarkup(text string, base *url.URL) (*Markup, []string, error) {
+	rendered, links := renderWithLinks(text, base, 80)
 
 	return &Markup{
 		text:        text,
+		base:        base,
 		cached:      rendered,
 		cachedWidth: 80,
 	}, links, nil
@@ -27,13 +32,13 @@ func (m *Markup) Render(width int) string {
 	if m.cachedWidth == width {
 		return m.cached
 	}
-	rendered, _ := renderWithLinks(m.text, width)
+	rendered, _ := renderWithLinks(m.text, m.base, width)
 	m.cached = rendered
 	m.cachedWidth = width
 	return rendered
 }
 
-func renderWithLinks(text string, width int) (string, []string) {
+func renderWithLinks(text string, base *url.URL, width int) (string, []string) {
 	/*
 		Oversimplistic URL regexp based on RFC 3986, Appendix A
 		It matches:
@@ -47,10 +52,14 @@ func renderWithLinks(text string, width int) (string, []string) {
 	links := []string{}
 
 	url := regexp.MustCompile(`[A-Za-z][A-Za-z0-9+\-.]*://[A-Za-z0-9.?#/@:%_~!$&'()*+,;=\[\]\-]+`)
-	rendered := url.ReplaceAllStringFunc(text, func(link string) string {
+	rendered := url.ReplaceAllStringFunc(text, func(match string) string {
+		link, err := href.Resolve(base, match)
+		if err != nil {
+			return match
+		}
 		links = append(links, link)
 
-		return style.Link(link, len(links))
+		return style.Link(match, len(links), link)
 	})
 	wrapped := ansi.Wrap(rendered, width)
 	return strings.Trim(wrapped, "\n"), links
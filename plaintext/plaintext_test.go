@@ -10,7 +10,7 @@ func TestBasic(t *testing.T) {
 	input := `Yes, Jim, I found it under "http://www.w3.org/Addressing/",
 but you can probably pick it up from the store.
 Note the warning in <http://www.ics.uci.edu/pub/ietf/uri/historical.html#WARNING>.`
-	markup, links, err := NewMarkup(input)
+	markup, links, err := NewMarkup(input, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -26,9 +26,9 @@ Note the warning in <http://www.ics.uci.edu/pub/ietf/uri/historical.html#WARNING
 		t.Fatalf("first uri should be http://www.ics.uci.edu/pub/ietf/uri/historical.html#WARNING not %s", second)
 	}
 
-	expected := ansi.Wrap("Yes, Jim, I found it under \""+style.Link("http://www.w3.org/Addressing/", 1)+
+	expected := ansi.Wrap("Yes, Jim, I found it under \""+style.Link("http://www.w3.org/Addressing/", 1, "http://www.w3.org/Addressing/")+
 		"\",\nbut you can probably pick it up from the store.\n"+
-		"Note the warning in <"+style.Link("http://www.ics.uci.edu/pub/ietf/uri/historical.html#WARNING", 2)+">.", 50)
+		"Note the warning in <"+style.Link("http://www.ics.uci.edu/pub/ietf/uri/historical.html#WARNING", 2, "http://www.ics.uci.edu/pub/ietf/uri/historical.html#WARNING")+">.", 50)
 
 	if expected != output {
 		t.Fatalf("expected markup to be %s not %s", expected, output)
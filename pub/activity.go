@@ -6,10 +6,12 @@ import (
 	"golang.org/x/exp/slices"
 	"servitor/ansi"
 	"servitor/client"
+	"servitor/config"
 	"servitor/mime"
 	"servitor/object"
 	"servitor/style"
 	"net/url"
+	"strings"
 	"sync"
 	"time"
 )
@@ -17,12 +19,17 @@ import (
 type Activity struct {
 	kind string
 	id   *url.URL
+	raw  object.Object
 
 	actor      *Actor
 	actorErr   error
 	created    time.Time
 	createdErr error
 	target     Tangible
+
+	/* The emoji of an EmojiReact */
+	reaction    string
+	reactionErr error
 }
 
 func NewActivity(input any, source *url.URL) (*Activity, error) {
@@ -34,30 +41,206 @@ func NewActivity(input any, source *url.URL) (*Activity, error) {
 }
 
 func NewActivityFromObject(o object.Object, id *url.URL) (*Activity, error) {
+	return newActivityFromObject(o, id, []string{})
+}
+
+/* The number of Announces and Undos that will be followed into the activities they wrap */
+const maxActivityDepth = 4
+
+/*
+wrappers holds the identifiers of the activities that (transitively) wrap
+this one, which is used to avoid following Announce and Undo cycles
+*/
+func newActivityFromObject(o object.Object, id *url.URL, wrappers []string) (*Activity, error) {
 	a := &Activity{}
 	a.id = id
+	a.raw = o
 	var err error
 	if a.kind, err = o.GetString("type"); err != nil {
 		return nil, err
 	}
 
-	if !slices.Contains([]string{
-		"Create", "Announce", "Dislike", "Like",
-	}, a.kind) {
+	if !slices.Contains(config.ActivityKinds, a.kind) {
 		return nil, fmt.Errorf("%w: %s is not an Activity", ErrWrongType, a.kind)
 	}
 
 	a.created, a.createdErr = o.GetTime("published")
+	a.reaction, a.reactionErr = o.GetString("content")
 
 	var wg sync.WaitGroup
 	wg.Add(2)
 	go func() { a.actor, a.actorErr = getActor(o, "actor", a.id); wg.Done() }()
-	go func() { a.target = getPostOrActor(o, "object", a.id); wg.Done() }()
+	go func() {
+		switch a.kind {
+		case "Delete":
+			/* The object is gone, so all that's left is what it was */
+			a.target = NewFailure(errors.New("the object was deleted"))
+		case "Undo":
+			a.target = getUndone(o, a.id, wrappers)
+		case "Move":
+			a.target = getPostOrActor(o, "target", a.id)
+		case "Flag":
+			a.target = getReported(o, a.id)
+		case "Announce":
+			a.target = getAnnounced(o, a.id, wrappers)
+		default:
+			a.target = getPostOrActor(o, "object", a.id)
+		}
+		wg.Done()
+	}()
 	wg.Wait()
 
 	return a, nil
 }
 
+/* Kinds that are shown as a line describing them, rather than with the object they act on */
+var summarizedKinds = []string{
+	"Update", "Delete", "Follow", "Add", "Remove", "Move", "Undo", "EmojiReact", "Block", "Flag",
+}
+
+/*
+Returns the wrappers of an activity nested within the one identified by
+`source`, or an error if `id`, the nested activity, is among them
+*/
+func nestActivity(wrappers []string, source *url.URL, id *url.URL) ([]string, error) {
+	if id != nil && (slices.Contains(wrappers, id.String()) || (source != nil && source.String() == id.String())) {
+		return nil, errActivityCycle
+	}
+
+	/* Cloned so sibling activities don't share a backing array */
+	wrappers = slices.Clone(wrappers)
+	if source != nil {
+		wrappers = append(wrappers, source.String())
+	} else {
+		wrappers = append(wrappers, "")
+	}
+	return wrappers, nil
+}
+
+/* The object of an Undo is an activity, which is usually embedded since it can't be fetched */
+func getUndone(o object.Object, source *url.URL, wrappers []string) Tangible {
+	reference, err := o.GetAny("object")
+	if err != nil {
+		return NewFailure(err)
+	}
+	if len(wrappers) >= maxActivityDepth {
+		return NewFailure(errActivityTooDeep)
+	}
+	fetched, id, err := client.FetchUnknown(reference, source)
+	if err != nil {
+		return NewFailure(err)
+	}
+	wrappers, err = nestActivity(wrappers, source, id)
+	if err != nil {
+		return NewFailure(err)
+	}
+	undone, err := newActivityFromObject(fetched, id, wrappers)
+	if err != nil {
+		return NewFailure(err)
+	}
+	return undone
+}
+
+/*
+Per FEP-1b12, groups announce the activities performed within them,
+e.g. a Create of a post or a Like of one. These are often embedded,
+since they can't always be fetched from the servers they came from.
+*/
+func getAnnounced(o object.Object, source *url.URL, wrappers []string) Tangible {
+	reference, err := o.GetAny("object")
+	if err != nil {
+		return NewFailure(err)
+	}
+	if len(wrappers) >= maxActivityDepth {
+		return NewFailure(errActivityTooDeep)
+	}
+
+	if asMap, ok := reference.(map[string]any); ok {
+		embedded := object.Object(asMap)
+		if kind, err := embedded.GetString("type"); err == nil && slices.Contains(config.ActivityKinds, kind) {
+			id, _ := embedded.GetURL("id")
+			nested, err := nestActivity(wrappers, source, id)
+			if err != nil {
+				return NewFailure(err)
+			}
+			announced, err := newActivityFromObject(embedded, id, nested)
+			if err != nil {
+				return NewFailure(err)
+			}
+			return announced
+		}
+	}
+
+	fetched, id, err := client.FetchUnknown(reference, source)
+	if err != nil {
+		return NewFailure(err)
+	}
+	nested, err := nestActivity(wrappers, source, id)
+	if err != nil {
+		return NewFailure(err)
+	}
+	announced, err := newActivityFromObject(fetched, id, nested)
+	if err == nil {
+		return announced
+	} else if !errors.Is(err, ErrWrongType) {
+		return NewFailure(err)
+	}
+	return newPostOrActorFromObject(fetched, id)
+}
+
+/* The object of a Flag lists the reported actor along with the posts being reported */
+func getReported(o object.Object, source *url.URL) Tangible {
+	list, err := o.GetList("object")
+	if err != nil {
+		return NewFailure(err)
+	}
+	if len(list) == 0 {
+		return NewFailure(errors.New("the report is of nothing"))
+	}
+	return getPostOrActor(object.Object{"object": list[0]}, "object", source)
+}
+
+/* Whether the activity is the announce of another activity, as groups do */
+func (a *Activity) Relayed() bool {
+	if a.kind != "Announce" {
+		return false
+	}
+	_, ok := a.target.(*Activity)
+	return ok
+}
+
+/* Returns the activity within any group announces */
+func (a *Activity) unwrapped() *Activity {
+	for a.Relayed() {
+		a = a.target.(*Activity)
+	}
+	return a
+}
+
+/* The kinds of the activity and those it wraps, from the outermost in */
+func (a *Activity) Verbs() []string {
+	verbs := []string{a.kind}
+	for a.Relayed() {
+		a = a.target.(*Activity)
+		verbs = append(verbs, a.kind)
+	}
+	return verbs
+}
+
+/* Names the group a relayed activity happened in, like Lemmy's !community@host */
+func (a *Activity) community() string {
+	if a.actorErr != nil {
+		return "in " + style.Problem(a.actorErr)
+	} else if a.actor.kind == "Group" && a.actor.id != nil && a.actor.handleErr == nil {
+		return "in " + style.Italic("!"+a.actor.handle+"@"+a.actor.id.Host)
+	}
+	return "via " + a.actor.Name()
+}
+
+func (a *Activity) summarized() bool {
+	return slices.Contains(summarizedKinds, a.kind)
+}
+
 func (a *Activity) header(width int) string {
 	if a.kind == "Create" {
 		return ""
@@ -74,30 +257,185 @@ func (a *Activity) header(width int) string {
 
 	switch a.kind {
 	case "Announce":
-		output += "retweeted"
+		output += "retweeted:\n"
 	case "Like":
-		output += "upvoted"
+		output += "upvoted:\n"
 	case "Dislike":
-		output += "downvoted"
+		output += "downvoted:\n"
+	default:
+		output += a.summary()
+		if a.createdErr != nil && !errors.Is(a.createdErr, object.ErrKeyNotPresent) {
+			output += " at " + style.Problem(a.createdErr)
+		} else if a.createdErr == nil {
+			output += " • " + style.Color(ago(a.created))
+		}
+	}
+
+	return ansi.Wrap(output, width)
+}
+
+/* Describes what a summarized activity did, following the name of its actor */
+func (a *Activity) summary() string {
+	switch a.kind {
+	case "Update":
+		return "edited " + describe(a.target)
+	case "Delete":
+		return a.deletion()
+	case "Follow":
+		return "followed " + describe(a.target)
+	case "Block":
+		return "blocked " + describe(a.target)
+	case "Flag":
+		return "reported " + describe(a.target)
+	case "Add":
+		if a.pinning() {
+			return "pinned " + describe(a.target)
+		}
+		return "added " + describe(a.target) + " to a collection"
+	case "Remove":
+		if a.pinning() {
+			return "unpinned " + describe(a.target)
+		}
+		return "removed " + describe(a.target) + " from a collection"
+	case "Move":
+		return "moved to " + describe(a.target)
+	case "EmojiReact":
+		if a.reactionErr != nil {
+			return "reacted to " + describe(a.target)
+		}
+		return "reacted " + a.reaction + " to " + describe(a.target)
+	case "Undo":
+		undone, ok := a.target.(*Activity)
+		if !ok {
+			return "undid an activity"
+		}
+		switch undone.kind {
+		case "Follow":
+			return "unfollowed " + describe(undone.target)
+		case "Block":
+			return "unblocked " + describe(undone.target)
+		case "Announce":
+			return "undid their retweet of " + describe(undone.target)
+		case "Like":
+			return "undid their upvote of " + describe(undone.target)
+		case "Dislike":
+			return "undid their downvote of " + describe(undone.target)
+		case "EmojiReact":
+			return "removed their reaction to " + describe(undone.target)
+		default:
+			return "undid an activity"
+		}
 	default:
 		panic("encountered unrecognized Activity type: " + a.kind)
 	}
+}
 
-	output += ":\n"
+/* Deleted objects are usually replaced by a Tombstone recording their former type */
+func (a *Activity) deletion() string {
+	reference, err := a.raw.GetAny("object")
+	if err != nil {
+		return "deleted something"
+	}
+	var deleted object.Object
+	switch narrowed := reference.(type) {
+	case map[string]any:
+		deleted = object.Object(narrowed)
+	case string:
+		deleted = object.Object{"id": narrowed}
+	default:
+		return "deleted something"
+	}
+	if id, err := deleted.GetURL("id"); err == nil && a.actorErr == nil && a.actor.Identifier() != nil {
+		if id.String() == a.actor.Identifier().String() {
+			return "deleted their account"
+		}
+	}
+	if kind, err := deleted.GetString("formerType"); err == nil {
+		return "deleted " + withArticle(kind)
+	}
+	return "deleted a post"
+}
 
-	return ansi.Wrap(output, width)
+/* Whether an Add or Remove is of the actor's pinned posts */
+func (a *Activity) pinning() bool {
+	collection, err := a.raw.GetURL("target")
+	if err != nil || a.actorErr != nil {
+		return false
+	}
+	featured, ok := a.actor.featured.identifier()
+	return ok && collection.String() == featured
+}
+
+/* Returns a short description of an item, for use within a sentence */
+func describe(item Tangible) string {
+	switch narrowed := item.(type) {
+	case *Actor:
+		return narrowed.Name()
+	case *Post:
+		if narrowed.titleErr == nil {
+			return "\u201c" + narrowed.title + "\u201d"
+		}
+		return withArticle(narrowed.kind)
+	case *Failure:
+		return "something that failed to load"
+	default:
+		return "something"
+	}
+}
+
+func withArticle(kind string) string {
+	switch kind {
+	case "Article":
+		return "an article"
+	case "Audio":
+		return "a recording"
+	case "Image":
+		return "an image"
+	case "Note":
+		return "a note"
+	case "":
+		return "an object"
+	default:
+		return "a " + strings.ToLower(kind)
+	}
 }
 
 func (a *Activity) String(width int) string {
+	if a.Relayed() {
+		/* The full view spells out what was announced, e.g. an Announce of an Update */
+		line := a.community() + " • " + style.Color(strings.Join(a.Verbs(), " of "))
+		return ansi.Wrap(line, width) + "\n" + a.unwrapped().String(width)
+	}
+
 	output := a.header(width)
 
+	if a.summarized() {
+		/* The deleted object is described by the header */
+		if a.kind == "Delete" {
+			return output
+		}
+		/* As is the undone activity, unless it failed to load */
+		if _, failed := a.target.(*Failure); a.kind == "Undo" && !failed {
+			return output
+		}
+		return output + "\n\n" + a.target.String(width)
+	}
+
 	output += a.target.String(width)
 	return output
 }
 
 func (a *Activity) Preview(width int) string {
+	if a.Relayed() {
+		return ansi.Wrap(a.community(), width) + "\n" + a.unwrapped().Preview(width)
+	}
+
 	output := a.header(width)
 
+	if a.summarized() {
+		return output
+	}
+
 	output += a.target.Preview(width)
 	return output
 }
@@ -111,7 +449,8 @@ func (a *Activity) Parents(quantity uint) ([]Tangible, Tangible) {
 }
 
 func (a *Activity) Timestamp() time.Time {
-	if errors.Is(a.createdErr, object.ErrKeyNotPresent) {
+	/* When the object was made says nothing about when a summarized activity happened */
+	if errors.Is(a.createdErr, object.ErrKeyNotPresent) && !a.summarized() {
 		return a.target.Timestamp()
 	} else if a.createdErr != nil {
 		return time.Time{}
@@ -138,8 +477,12 @@ func (a *Activity) ActorIdentifier() *url.URL {
 	return a.actor.Identifier()
 }
 
+func (a *Activity) Kind() string {
+	return a.kind
+}
+
 func (a *Activity) Target() Tangible {
-	return a.target
+	return a.unwrapped().target
 }
 
 func (a *Activity) SelectLink(input int) (string, *mime.MediaType, bool) {
@@ -6,11 +6,14 @@ import (
 	"golang.org/x/exp/slices"
 	"servitor/ansi"
 	"servitor/client"
+	"servitor/config"
+	"servitor/hypertext"
 	"servitor/mime"
 	"servitor/object"
 	"servitor/style"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -21,7 +24,8 @@ type Actor struct {
 	handle    string
 	handleErr error
 
-	id *url.URL
+	id  *url.URL
+	raw object.Object
 
 	bio      object.Markup
 	bioLinks []string
@@ -37,6 +41,32 @@ type Actor struct {
 
 	posts    *Collection
 	postsErr error
+
+	followers    *deferredCollection
+	following    *deferredCollection
+	featured     *deferredCollection
+	featuredTags *deferredCollection
+
+	fields    []field
+	fieldsErr error
+
+	movedTo       *url.URL
+	movedToHandle string
+	movedToErr    error
+
+	aliases    []string
+	aliasesErr error
+
+	/* Whether the profile picture and banner are drawn */
+	showImages bool
+}
+
+/* An entry in a profile's table of metadata */
+type field struct {
+	name     string
+	nameErr  error
+	value    object.Markup
+	valueErr error
 }
 
 func NewActor(input any, source *url.URL) (*Actor, error) {
@@ -50,6 +80,8 @@ func NewActor(input any, source *url.URL) (*Actor, error) {
 func NewActorFromObject(o object.Object, id *url.URL) (*Actor, error) {
 	a := &Actor{}
 	a.id = id
+	a.raw = o
+	a.showImages = config.Parsed.Media.InlineImages
 	var err error
 	if a.kind, err = o.GetString("type"); err != nil {
 		return nil, err
@@ -63,9 +95,22 @@ func NewActorFromObject(o object.Object, id *url.URL) (*Actor, error) {
 
 	a.name, a.nameErr = o.GetString("name")
 	a.handle, a.handleErr = o.GetString("preferredUsername")
-	a.bio, a.bioLinks, a.bioErr = o.GetMarkup("summary", "mediaType")
+	a.bio, a.bioLinks, a.bioErr = o.GetMarkup("summary", "mediaType", a.id)
+	if config.Parsed.Style.PreferSource {
+		if bio, links, err := getSource(o, a.id); err == nil {
+			a.bio, a.bioLinks, a.bioErr = bio, links, nil
+		}
+	}
 	a.joined, a.joinedErr = o.GetTime("published")
 
+	/* The links of the fields and aliases are numbered after those of the bio */
+	var fieldLinks []string
+	a.fields, fieldLinks, a.fieldsErr = getFields(o, a.id, len(a.bioLinks))
+	a.bioLinks = append(a.bioLinks, fieldLinks...)
+	a.aliases, a.aliasesErr = getAliases(o, a.id)
+	a.bioLinks = append(a.bioLinks, a.aliases...)
+	a.movedTo, a.movedToHandle, a.movedToErr = getMovedTo(o, a.id)
+
 	a.pfp, a.pfpErr = getBestLink(o, "icon", "image")
 	a.banner, a.bannerErr = getBestLink(o, "image", "image")
 
@@ -85,14 +130,144 @@ func NewActorFromObject(o object.Object, id *url.URL) (*Actor, error) {
 
 		return activity
 	})
+
+	constructActor := func(input any, source *url.URL) Tangible {
+		actor, err := NewActor(input, source)
+		if err != nil {
+			return NewFailure(err)
+		}
+		return actor
+	}
+	a.followers = getDeferredCollection(o, "followers", a.id, constructActor)
+	a.following = getDeferredCollection(o, "following", a.id, constructActor)
+	a.featured = getDeferredCollection(o, "featured", a.id, func(input any, source *url.URL) Tangible {
+		post, err := NewPost(input, source)
+		if err != nil {
+			return NewFailure(err)
+		}
+		post.pinned = true
+		return post
+	})
+	a.featuredTags = getDeferredCollection(o, "featuredTags", a.id, constructHashtag)
+
 	return a, nil
 }
 
+/* Mastodon represents profile metadata as PropertyValues with HTML values */
+func getFields(o object.Object, source *url.URL, offset int) ([]field, []string, error) {
+	list, err := o.GetList("attachment")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fields := []field{}
+	links := []string{}
+	for _, element := range list {
+		asMap, ok := element.(map[string]any)
+		if !ok {
+			continue
+		}
+		entry := object.Object(asMap)
+		if kind, err := entry.GetString("type"); err != nil || kind != "PropertyValue" {
+			continue
+		}
+
+		f := field{}
+		f.name, f.nameErr = entry.GetString("name")
+		value, err := entry.GetString("value")
+		if err != nil {
+			f.valueErr = err
+		} else {
+			var valueLinks []string
+			f.value, valueLinks, f.valueErr = hypertext.NewMarkupWithOffset(value, source, offset+len(links))
+			links = append(links, valueLinks...)
+		}
+		fields = append(fields, f)
+	}
+
+	return fields, links, nil
+}
+
+func getAliases(o object.Object, source *url.URL) ([]string, error) {
+	list, err := o.GetList("alsoKnownAs")
+	if err != nil {
+		return nil, err
+	}
+
+	aliases := make([]string, 0, len(list))
+	for _, element := range list {
+		alias, ok := element.(string)
+		if !ok {
+			return nil, fmt.Errorf("alias is a %T rather than a string", element)
+		}
+		address, err := url.Parse(alias)
+		if err != nil {
+			return nil, err
+		}
+		if source != nil {
+			address = source.ResolveReference(address)
+		}
+		/* Some software lists the actor among its own aliases */
+		if source != nil && address.String() == source.String() {
+			continue
+		}
+		aliases = append(aliases, address.String())
+	}
+	return aliases, nil
+}
+
+/*
+The new account is fetched as a plain object rather than an Actor
+so that accounts that were moved back and forth don't cause a loop
+*/
+func getMovedTo(o object.Object, source *url.URL) (*url.URL, string, error) {
+	target, id, err := getAndFetchUnkown(o, "movedTo", source)
+	if err != nil {
+		return nil, "", err
+	}
+	if id == nil {
+		return nil, "", errors.New("account moved to an account that lacks an identifier")
+	}
+	handle, err := target.GetString("preferredUsername")
+	if err != nil {
+		return id, id.String(), nil
+	}
+	return id, "@" + handle + "@" + id.Host, nil
+}
+
+/*
+Fetches the collections that are only counted on the actor's
+own page, which is too many requests to make for every actor
+*/
+func (a *Actor) Prefetch() {
+	var wg sync.WaitGroup
+	for _, collection := range []*deferredCollection{a.followers, a.following, a.featured} {
+		if !collection.present() {
+			continue
+		}
+		collection := collection
+		wg.Add(1)
+		go func() { collection.fetch(); wg.Done() }()
+	}
+	wg.Wait()
+}
+
 func (a *Actor) Parents(quantity uint) ([]Tangible, Tangible) {
 	return []Tangible{}, nil
 }
 
+/* Pinned posts are listed before the rest of the actor's posts */
 func (a *Actor) Children() Container {
+	if !a.featured.present() {
+		return a.Posts()
+	}
+	if a.posts == nil {
+		return a.featured
+	}
+	return chain{a.featured, a.posts}
+}
+
+func (a *Actor) Posts() Container {
 	/* the if is necessary because my understanding is
 	   the first nil is a (*Collection)(nil) whereas
 	   the second is (Container)(nil) */
@@ -103,6 +278,26 @@ func (a *Actor) Children() Container {
 	}
 }
 
+/* Returns the followers collection (or a Failure), which may require a request */
+func (a *Actor) Followers() (any, bool) {
+	return a.followers.open()
+}
+
+/* Returns the following collection (or a Failure), which may require a request */
+func (a *Actor) Following() (any, bool) {
+	return a.following.open()
+}
+
+/* Returns the pinned posts collection (or a Failure), which may require a request */
+func (a *Actor) Pinned() (any, bool) {
+	return a.featured.open()
+}
+
+/* Returns the featured hashtags collection (or a Failure), which may require a request */
+func (a *Actor) FeaturedTags() (any, bool) {
+	return a.featuredTags.open()
+}
+
 func (a *Actor) Name() string {
 	var output string
 	if a.nameErr == nil {
@@ -137,6 +332,14 @@ func (a *Actor) Name() string {
 func (a *Actor) header(width int) string {
 	output := a.Name()
 
+	if errors.Is(a.movedToErr, object.ErrKeyNotPresent) {
+		// omit it
+	} else if a.movedToErr != nil {
+		output += "\nmoved to " + style.Problem(a.movedToErr)
+	} else {
+		output += "\n" + style.Highlight(style.Bold("moved to "+a.movedToHandle))
+	}
+
 	if errors.Is(a.joinedErr, object.ErrKeyNotPresent) {
 		// omit it
 	} else if a.joinedErr != nil {
@@ -160,7 +363,122 @@ func (a *Actor) center(width int) (string, bool) {
 	return rendered, true
 }
 
+func (a *Actor) profile(width int) (string, bool) {
+	output := ""
+
+	if errors.Is(a.fieldsErr, object.ErrKeyNotPresent) {
+		// omit it
+	} else if a.fieldsErr != nil {
+		output += ansi.Wrap(style.Problem(a.fieldsErr), width)
+	} else if len(a.fields) != 0 {
+		output += fieldTable(a.fields, width)
+	}
+
+	if errors.Is(a.aliasesErr, object.ErrKeyNotPresent) || (a.aliasesErr == nil && len(a.aliases) == 0) {
+		// omit it
+	} else {
+		if output != "" {
+			output += "\n\n"
+		}
+		output += style.Color("also known as:")
+		if a.aliasesErr != nil {
+			output += " " + style.Problem(a.aliasesErr)
+		}
+		/* The aliases are the final links */
+		first := len(a.bioLinks) - len(a.aliases) + 1
+		for i, alias := range a.aliases {
+			output += "\n" + style.LinkBlock(ansi.Wrap(alias, width-2), first+i, alias)
+		}
+	}
+
+	return output, output != ""
+}
+
+/* Lays out the fields in two columns, names on the left and values on the right */
+func fieldTable(fields []field, width int) string {
+	nameWidth := 1
+	for _, f := range fields {
+		if length := ansi.Width(f.name); length > nameWidth {
+			nameWidth = length
+		}
+	}
+	if nameWidth > width/3 {
+		nameWidth = width / 3
+	}
+	valueWidth := width - nameWidth - 3
+	if nameWidth < 1 || valueWidth < 1 {
+		nameWidth, valueWidth = width, width
+	}
+
+	rows := make([]string, len(fields))
+	for i, f := range fields {
+		var name, value string
+		if f.nameErr != nil {
+			name = style.Problem(f.nameErr)
+		} else {
+			name = style.Bold(f.name)
+		}
+		if f.valueErr != nil {
+			value = ansi.Wrap(style.Problem(f.valueErr), valueWidth)
+		} else {
+			value = f.value.Render(valueWidth)
+		}
+
+		if nameWidth == width {
+			rows[i] = ansi.Wrap(name, width) + "\n" + value
+			continue
+		}
+
+		nameLines := strings.Split(ansi.Pad(ansi.Wrap(name, nameWidth), nameWidth), "\n")
+		valueLines := strings.Split(value, "\n")
+		for j := 0; j < len(nameLines) || j < len(valueLines); j++ {
+			if j != 0 {
+				rows[i] += "\n"
+			}
+			if j < len(nameLines) {
+				rows[i] += nameLines[j]
+			} else {
+				rows[i] += strings.Repeat(" ", nameWidth)
+			}
+			rows[i] += style.Color(" │ ")
+			if j < len(valueLines) {
+				rows[i] += valueLines[j]
+			}
+		}
+	}
+	return strings.Join(rows, "\n")
+}
+
 func (a *Actor) footer(width int) (string, bool) {
+	output, present := a.postCount()
+
+	for _, tally := range []struct {
+		collection       *deferredCollection
+		singular, plural string
+	}{
+		{a.followers, "follower", "followers"},
+		{a.following, "following", "following"},
+		{a.featured, "pinned", "pinned"},
+	} {
+		quantity, known := tally.collection.size()
+		if !known {
+			continue
+		}
+		if present {
+			output += " • "
+		}
+		if quantity == 1 {
+			output += style.Color(fmt.Sprintf("%d %s", quantity, tally.singular))
+		} else {
+			output += style.Color(fmt.Sprintf("%d %s", quantity, tally.plural))
+		}
+		present = true
+	}
+
+	return ansi.Wrap(output, width), present
+}
+
+func (a *Actor) postCount() (string, bool) {
 	if a.postsErr != nil {
 		return style.Problem(a.postsErr), true
 	} else if quantity, err := a.posts.Size(); errors.Is(err, object.ErrKeyNotPresent) {
@@ -174,14 +492,57 @@ func (a *Actor) footer(width int) (string, bool) {
 	}
 }
 
+/* The profile picture is drawn small, like an avatar */
+const pfpWidth = 16
+
+func (a *Actor) drawPfp(width int) (string, bool) {
+	if a.pfpErr != nil {
+		return "", false
+	}
+	if width > pfpWidth {
+		width = pfpWidth
+	}
+	return a.pfp.draw(width)
+}
+
+/* Draws the banner with the profile picture beneath it */
+func (a *Actor) pictures(width int) (string, bool) {
+	images := []string{}
+	if a.bannerErr == nil {
+		if banner, drawn := a.banner.draw(width); drawn {
+			images = append(images, banner)
+		}
+	}
+	if pfp, drawn := a.drawPfp(width); drawn {
+		images = append(images, pfp)
+	}
+	return strings.Join(images, "\n"), len(images) != 0
+}
+
+/* Switches between drawing the profile picture and banner and only linking them */
+func (a *Actor) ToggleImages() {
+	a.showImages = !a.showImages
+}
+
 func (a *Actor) String(width int) string {
-	output := a.header(width)
+	output := ""
+	if a.showImages {
+		if pictures, drawn := a.pictures(width); drawn {
+			output += pictures + "\n"
+		}
+	}
+	output += a.header(width)
 
 	body, bodyPresent := a.center(width - 4)
 	if bodyPresent {
 		output += "\n\n" + ansi.Indent(body, "  ", true)
 	}
 
+	if profile, present := a.profile(width - 4); present {
+		output += "\n\n" + ansi.Indent(profile, "  ", true)
+		bodyPresent = true
+	}
+
 	if footer, present := a.footer(width); present {
 		if bodyPresent {
 			output += "\n"
@@ -203,6 +564,12 @@ func (a *Actor) Preview(width int) string {
 		output += "\n" + footer
 	}
 
+	if a.showImages {
+		if pfp, drawn := a.drawPfp(width); drawn {
+			output += "\n" + pfp
+		}
+	}
+
 	return output
 }
 
@@ -218,6 +585,21 @@ func (a *Actor) Identifier() *url.URL {
 	return a.id
 }
 
+func (a *Actor) MovedTo() (string, bool) {
+	if a.movedToErr != nil {
+		return "", false
+	}
+	return a.movedTo.String(), true
+}
+
+/* Returns the address of the account the actor moved to, in the form used by feeds */
+func (a *Actor) MovedToAddress() (string, bool) {
+	if a.movedToErr != nil {
+		return "", false
+	}
+	return a.movedToHandle, true
+}
+
 func (a *Actor) Banner() (string, *mime.MediaType, bool) {
 	if a.bannerErr != nil {
 		return "", nil, false
@@ -0,0 +1,163 @@
+package pub
+
+import (
+	"errors"
+	"golang.org/x/exp/slices"
+	"servitor/object"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+/*
+	Posts are addressed with to, cc, bto, and bcc, and how public a post
+	is comes from who it is addressed to:
+	  - public posts are addressed to the special public collection
+	  - unlisted posts only carbon copy it, so they are hidden from timelines
+	  - followers-only posts are addressed to the followers of their creator
+	  - direct posts are addressed only to those mentioned in them
+	See: https://www.w3.org/TR/activitypub/#public-addressing
+*/
+
+/* The ways the public collection may be written, since JSON-LD allows compacting it */
+var publicAliases = []string{
+	"https://www.w3.org/ns/activitystreams#Public", "as:Public", "Public",
+}
+
+var addressingKeys = []string{"to", "cc", "bto", "bcc"}
+
+/* Returns the addresses under each key, absent keys being empty */
+func getAddresses(o object.Object, source *url.URL) (map[string][]string, error) {
+	addresses := map[string][]string{}
+	present := false
+	for _, key := range addressingKeys {
+		list, err := o.GetList(key)
+		if errors.Is(err, object.ErrKeyNotPresent) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		present = true
+		for _, element := range list {
+			if address, ok := resolveAddress(element, source); ok {
+				addresses[key] = append(addresses[key], address)
+			}
+		}
+	}
+	if !present {
+		return nil, object.ErrKeyNotPresent
+	}
+	return addresses, nil
+}
+
+/* Addresses are usually links but may be embedded actors or collections */
+func resolveAddress(reference any, source *url.URL) (string, bool) {
+	var address string
+	switch narrowed := reference.(type) {
+	case string:
+		address = narrowed
+	case map[string]any:
+		id, err := object.Object(narrowed).GetString("id")
+		if err != nil {
+			return "", false
+		}
+		address = id
+	default:
+		return "", false
+	}
+	if slices.Contains(publicAliases, address) {
+		return publicAliases[0], true
+	}
+	parsed, err := url.Parse(address)
+	if err != nil {
+		return "", false
+	}
+	if source != nil {
+		parsed = source.ResolveReference(parsed)
+	}
+	return parsed.String(), true
+}
+
+/* The followers collections of the post's creators */
+func (p *Post) followersCollections() []string {
+	collections := []string{}
+	for _, creator := range p.creators {
+		actor, ok := creator.(*Actor)
+		if !ok {
+			continue
+		}
+		if followers, ok := actor.followers.identifier(); ok {
+			collections = append(collections, followers)
+		}
+	}
+	return collections
+}
+
+/*
+Whether the address is of a followers collection; the creators may not
+have loaded, and other actors' followers may be addressed as well, so
+this falls back to how followers collections are usually named
+*/
+func isFollowers(address string, followers []string) bool {
+	return slices.Contains(followers, address) || strings.HasSuffix(address, "/followers")
+}
+
+/* Expects the creators to have been fetched */
+func (p *Post) computeVisibility() (string, error) {
+	if p.addressesErr != nil {
+		return "", p.addressesErr
+	}
+	if slices.Contains(p.addresses["to"], publicAliases[0]) {
+		return "public", nil
+	}
+	if slices.Contains(p.addresses["cc"], publicAliases[0]) {
+		return "unlisted", nil
+	}
+	followers := p.followersCollections()
+	for _, key := range addressingKeys {
+		for _, address := range p.addresses[key] {
+			if isFollowers(address, followers) {
+				return "followers only", nil
+			}
+		}
+	}
+	return "direct", nil
+}
+
+/*
+Returns every actor the post is addressed to, which requires
+fetching them; the public collection and followers collections
+are left out since they aren't actors
+*/
+func (p *Post) Addressees() []Tangible {
+	if p.addressesErr != nil {
+		if errors.Is(p.addressesErr, object.ErrKeyNotPresent) {
+			return []Tangible{}
+		}
+		return []Tangible{NewFailure(p.addressesErr)}
+	}
+
+	followers := p.followersCollections()
+	addresses := []string{}
+	for _, key := range addressingKeys {
+		for _, address := range p.addresses[key] {
+			if address == publicAliases[0] || isFollowers(address, followers) || slices.Contains(addresses, address) {
+				continue
+			}
+			addresses = append(addresses, address)
+		}
+	}
+
+	output := make([]Tangible, len(addresses))
+	var wg sync.WaitGroup
+	for i := range addresses {
+		wg.Add(1)
+		i := i
+		go func() {
+			output[i] = constructRetryably(constructActor, addresses[i], nil)
+			wg.Done()
+		}()
+	}
+	wg.Wait()
+	return output
+}
@@ -35,6 +35,12 @@ type Collection struct {
 	elementsErr error
 	next        any
 	nextErr     error
+	prev        any
+	prevErr     error
+	last        any
+	lastErr     error
+	partOf      any
+	partOfErr   error
 
 	size    uint64
 	sizeErr error
@@ -74,8 +80,19 @@ func NewCollectionFromObject(o object.Object, id *url.URL, construct func(any, *
 
 	if c.kind == "Collection" || c.kind == "OrderedCollection" {
 		c.next, c.nextErr = o.GetAny("first")
+		/* current is the page with the most recently updated items,
+		   which is as good a place as any to start if first is absent */
+		if errors.Is(c.nextErr, object.ErrKeyNotPresent) {
+			c.next, c.nextErr = o.GetAny("current")
+		}
+		c.last, c.lastErr = o.GetAny("last")
+		c.prevErr = object.ErrKeyNotPresent
+		c.partOfErr = object.ErrKeyNotPresent
 	} else {
 		c.next, c.nextErr = o.GetAny("next")
+		c.prev, c.prevErr = o.GetAny("prev")
+		c.partOf, c.partOfErr = o.GetAny("partOf")
+		c.lastErr = object.ErrKeyNotPresent
 	}
 
 	c.size, c.sizeErr = o.GetNumber("totalItems")
@@ -141,7 +158,7 @@ func (c *Collection) harvestWithEmptyCount(amount uint, startingPoint uint, empt
 		i := i
 		wg.Add(1)
 		go func() {
-			fromThisPage[i] = c.construct(c.elements[i+startingPoint], c.id)
+			fromThisPage[i] = constructRetryably(c.construct, c.elements[i+startingPoint], c.id)
 			wg.Done()
 		}()
 	}
@@ -167,3 +184,181 @@ func (c *Collection) harvestWithEmptyCount(amount uint, startingPoint uint, empt
 
 	return append(fromThisPage, fromLaterPages...), nextCollection, nextStartingPoint
 }
+
+/* A chain harvests each of its containers in turn */
+type chain []Container
+
+func (c chain) Harvest(quantity uint, startingPoint uint) ([]Tangible, Container, uint) {
+	if len(c) == 0 {
+		return []Tangible{}, nil, 0
+	}
+
+	items, next, nextStartingPoint := c[0].Harvest(quantity, startingPoint)
+
+	if next != nil {
+		remaining := append(chain{next}, c[1:]...)
+		return items, remaining, nextStartingPoint
+	}
+
+	if len(c) == 1 {
+		return items, nil, 0
+	}
+
+	if uint(len(items)) >= quantity {
+		return items, c[1:], 0
+	}
+
+	later, next, nextStartingPoint := c[1:].Harvest(quantity-uint(len(items)), 0)
+	return append(items, later...), next, nextStartingPoint
+}
+
+/* A reversed chain reads its containers in the opposite order, each reversed */
+func (c chain) Reverse() (Container, error) {
+	reversed := make(chain, len(c))
+	for i, container := range c {
+		r, err := container.Reverse()
+		if err != nil {
+			return nil, err
+		}
+		reversed[len(c)-1-i] = r
+	}
+	return reversed, nil
+}
+
+/*
+Returns the collection read from its end, which for the usual
+newest-first collections means reading the oldest items first.
+Pages are reversed by reversing the collection they are part of.
+*/
+func (c *Collection) Reverse() (Container, error) {
+	if c.kind == "CollectionPage" || c.kind == "OrderedCollectionPage" {
+		if c.partOfErr != nil {
+			return nil, fmt.Errorf("can't find the end of a page without partOf: %w", c.partOfErr)
+		}
+		whole, err := NewCollection(c.partOf, c.id, c.construct)
+		if err != nil {
+			return nil, err
+		}
+		return whole.Reverse()
+	}
+
+	if c.lastErr != nil {
+		/* A collection that holds all of its items needs no last page */
+		if errors.Is(c.lastErr, object.ErrKeyNotPresent) && errors.Is(c.nextErr, object.ErrKeyNotPresent) {
+			return &reversedCollection{page: c, origin: c}, nil
+		}
+		return nil, fmt.Errorf("collection can't be read from its end: %w", c.lastErr)
+	}
+	last, err := NewCollection(c.last, c.id, c.construct)
+	if err != nil {
+		return nil, err
+	}
+	return &reversedCollection{page: last, origin: c}, nil
+}
+
+/* A reversedCollection reads the items of each page backwards, following prev */
+type reversedCollection struct {
+	page   *Collection
+	origin *Collection
+}
+
+func (r *reversedCollection) Harvest(quantity uint, startingPoint uint) ([]Tangible, Container, uint) {
+	return r.harvestWithEmptyCount(quantity, startingPoint, 0)
+}
+
+/* startingPoint is the number of items already read from the end of the page */
+func (r *reversedCollection) harvestWithEmptyCount(amount uint, startingPoint uint, emptyCount int) ([]Tangible, Container, uint) {
+	c := r.page
+	if c.elementsErr != nil && !errors.Is(c.elementsErr, object.ErrKeyNotPresent) {
+		return []Tangible{NewFailure(c.elementsErr)}, nil, 0
+	}
+
+	var length uint
+	if errors.Is(c.elementsErr, object.ErrKeyNotPresent) {
+		length = 0
+	} else {
+		length = uint(len(c.elements))
+	}
+
+	if length == 0 {
+		emptyCount += 1
+	}
+
+	/* See harvestWithEmptyCount on Collection */
+	if emptyCount > 3 {
+		return []Tangible{NewFailure(errors.New("refusing to read the previous collection because >3 consecutive empty collections have been encountered"))}, nil, 0
+	}
+
+	var amountFromThisPage uint
+	if startingPoint >= length {
+		amountFromThisPage = 0
+	} else if length > amount+startingPoint {
+		amountFromThisPage = amount
+	} else {
+		amountFromThisPage = length - startingPoint
+	}
+
+	fromThisPage := make([]Tangible, amountFromThisPage)
+	var fromEarlierPages []Tangible
+	var nextCollection Container
+	var nextStartingPoint uint
+
+	var wg sync.WaitGroup
+	for i := uint(0); i < amountFromThisPage; i++ {
+		i := i
+		wg.Add(1)
+		go func() {
+			fromThisPage[i] = constructRetryably(c.construct, c.elements[length-1-startingPoint-i], c.id)
+			wg.Done()
+		}()
+	}
+
+	wg.Add(1)
+	go func() {
+		if length > amount+startingPoint {
+			fromEarlierPages, nextCollection, nextStartingPoint = []Tangible{}, r, amount+startingPoint
+		} else if errors.Is(c.prevErr, object.ErrKeyNotPresent) {
+			fromEarlierPages, nextCollection, nextStartingPoint = []Tangible{}, nil, 0
+		} else if c.prevErr != nil {
+			fromEarlierPages, nextCollection, nextStartingPoint = []Tangible{NewFailure(c.prevErr)}, nil, 0
+		} else if prev, err := NewCollection(c.prev, c.id, c.construct); err != nil {
+			fromEarlierPages, nextCollection, nextStartingPoint = []Tangible{NewFailure(err)}, nil, 0
+		} else {
+			earlier := &reversedCollection{page: prev, origin: r.origin}
+			fromEarlierPages, nextCollection, nextStartingPoint = earlier.harvestWithEmptyCount(amount-amountFromThisPage, 0, emptyCount)
+		}
+
+		wg.Done()
+	}()
+
+	wg.Wait()
+
+	return append(fromThisPage, fromEarlierPages...), nextCollection, nextStartingPoint
+}
+
+func (r *reversedCollection) Reverse() (Container, error) {
+	return r.origin, nil
+}
+
+/* A list is a container of items that are already in memory */
+type list []Tangible
+
+func (l list) Harvest(quantity uint, startingPoint uint) ([]Tangible, Container, uint) {
+	if startingPoint >= uint(len(l)) {
+		return []Tangible{}, nil, 0
+	}
+	/* Cloned since callers may append to the result */
+	end := startingPoint + quantity
+	if end >= uint(len(l)) {
+		return slices.Clone(l[startingPoint:]), nil, 0
+	}
+	return slices.Clone(l[startingPoint:end]), l, end
+}
+
+func (l list) Reverse() (Container, error) {
+	reversed := make(list, len(l))
+	for i, item := range l {
+		reversed[len(l)-1-i] = item
+	}
+	return reversed, nil
+}
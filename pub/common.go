@@ -7,11 +7,18 @@ import (
 	"servitor/object"
 	"net/url"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 var (
 	ErrWrongType = errors.New("item is the wrong type")
+
+	errQuoteTooDeep = errors.New("quote is nested too deeply")
+	errQuoteCycle   = errors.New("quote forms a cycle")
+
+	errActivityTooDeep = errors.New("activity is nested too deeply")
+	errActivityCycle   = errors.New("activity forms a cycle")
 )
 
 func getActors(o object.Object, key string, source *url.URL) []Tangible {
@@ -28,12 +35,7 @@ func getActors(o object.Object, key string, source *url.URL) []Tangible {
 		wg.Add(1)
 		i := i
 		go func() {
-			fetched, err := NewActor(list[i], source)
-			if err != nil {
-				output[i] = NewFailure(err)
-			} else {
-				output[i] = fetched
-			}
+			output[i] = constructRetryably(constructActor, list[i], source)
 			wg.Done()
 		}()
 	}
@@ -41,6 +43,14 @@ func getActors(o object.Object, key string, source *url.URL) []Tangible {
 	return output
 }
 
+func constructActor(input any, source *url.URL) Tangible {
+	actor, err := NewActor(input, source)
+	if err != nil {
+		return NewFailure(err)
+	}
+	return actor
+}
+
 func getPostOrActor(o object.Object, key string, source *url.URL) Tangible {
 	reference, err := o.GetAny(key)
 	if err != nil {
@@ -67,7 +77,10 @@ func getPostOrActor(o object.Object, key string, source *url.URL) Tangible {
 	if err != nil {
 		return NewFailure(err)
 	}
+	return newPostOrActorFromObject(o, id)
+}
 
+func newPostOrActorFromObject(o object.Object, id *url.URL) Tangible {
 	var fetched Tangible
 	var postErr, actorErr error
 	fetched, postErr = NewPostFromObject(o, id)
@@ -98,6 +111,106 @@ func getCollection(o object.Object, key string, source *url.URL, construct func(
 	return fetched, nil
 }
 
+/*
+A deferredCollection is only fetched once it is needed, which
+avoids making several requests per post merely to count things
+like likes and shares that are rarely opened
+*/
+type deferredCollection struct {
+	reference    any
+	referenceErr error
+	source       *url.URL
+	construct    func(any, *url.URL) Tangible
+
+	once       sync.Once
+	done       atomic.Bool
+	collection *Collection
+	err        error
+}
+
+func getDeferredCollection(o object.Object, key string, source *url.URL, construct func(any, *url.URL) Tangible) *deferredCollection {
+	d := &deferredCollection{
+		source:    source,
+		construct: construct,
+	}
+	d.reference, d.referenceErr = o.GetAny(key)
+
+	/* Inline collections usually need no request, so they
+	   are constructed immediately to allow them to be counted */
+	if _, inline := d.reference.(map[string]any); inline {
+		d.fetch()
+	}
+
+	return d
+}
+
+func (d *deferredCollection) present() bool {
+	return d.referenceErr == nil
+}
+
+func (d *deferredCollection) fetch() (*Collection, error) {
+	d.once.Do(func() {
+		if d.referenceErr != nil {
+			d.err = d.referenceErr
+		} else {
+			d.collection, d.err = NewCollection(d.reference, d.source, d.construct)
+		}
+		d.done.Store(true)
+	})
+	return d.collection, d.err
+}
+
+/* Returns the size of the collection if it has already been fetched */
+func (d *deferredCollection) size() (uint64, bool) {
+	if !d.done.Load() || d.err != nil {
+		return 0, false
+	}
+	quantity, err := d.collection.Size()
+	if err != nil {
+		return 0, false
+	}
+	return quantity, true
+}
+
+/* Returns the identifier of the collection if it is known without fetching it */
+func (d *deferredCollection) identifier() (string, bool) {
+	if d.referenceErr != nil {
+		return "", false
+	}
+	return resolveAddress(d.reference, d.source)
+}
+
+func (d *deferredCollection) Harvest(quantity uint, startingPoint uint) ([]Tangible, Container, uint) {
+	if !d.present() {
+		return []Tangible{}, nil, 0
+	}
+	collection, err := d.fetch()
+	if err != nil {
+		return []Tangible{NewFailure(err)}, nil, 0
+	}
+	return collection.Harvest(quantity, startingPoint)
+}
+
+func (d *deferredCollection) Reverse() (Container, error) {
+	collection, err := d.fetch()
+	if err != nil {
+		return nil, err
+	}
+	return collection.Reverse()
+}
+
+/* Fetches the collection, returning either it or a Failure */
+func (d *deferredCollection) open() (any, bool) {
+	if !d.present() {
+		return nil, false
+	}
+	collection, err := d.fetch()
+	if err != nil {
+		return NewFailure(err), true
+	}
+	return collection, true
+}
+
 func getActor(o object.Object, key string, source *url.URL) (*Actor, error) {
 	reference, err := o.GetAny(key)
 	if err != nil {
@@ -128,7 +241,16 @@ func NewTangible(input any, source *url.URL) Tangible {
 	return NewFailure(errors.New("item is a collection"))
 }
 
+/* Failures can be retried, which constructs the item again as a Tangible */
 func New(input any, source *url.URL) any {
+	result := fetchAndConstruct(input, source)
+	if failure, ok := result.(*Failure); ok && failure.construct == nil {
+		failure.retryWith(NewTangible, input, source)
+	}
+	return result
+}
+
+func fetchAndConstruct(input any, source *url.URL) any {
 	o, id, err := client.FetchUnknown(input, source)
 	if err != nil {
 		return NewFailure(err)
@@ -167,6 +289,51 @@ func New(input any, source *url.URL) any {
 	return NewFailure(errors.New("item is of unrecognized type"))
 }
 
+/*
+Lemmy, Misskey, PeerTube and Pleroma publish the author's original text
+under source, which is only rendered when it is in one of these formats,
+since HTML there is no better than the content
+*/
+var sourceMediaTypes = []string{"text/markdown", "text/plain", "text/gemini", "text/x.misskey.markdown"}
+
+func getSource(o object.Object, base *url.URL) (object.Markup, []string, error) {
+	source, err := o.GetObject("source")
+	if err != nil {
+		return nil, nil, err
+	}
+	mediaType, err := source.GetMediaType("mediaType")
+	if err != nil {
+		return nil, nil, err
+	}
+	if !mediaType.Matches(sourceMediaTypes) {
+		return nil, nil, errors.New("source is not richer than the content: " + mediaType.Essence)
+	}
+	return source.GetMarkup("content", "mediaType", base)
+}
+
+/* The hrefs of an object's Mention and Hashtag tags; malformed tags are skipped */
+func getTagged(o object.Object) map[string]bool {
+	tagged := map[string]bool{}
+	tags, err := o.GetList("tag")
+	if err != nil {
+		return tagged
+	}
+	for _, element := range tags {
+		asMap, ok := element.(map[string]any)
+		if !ok {
+			continue
+		}
+		tag := object.Object(asMap)
+		if kind, err := tag.GetString("type"); err != nil || (kind != "Mention" && kind != "Hashtag") {
+			continue
+		}
+		if href, err := tag.GetString("href"); err == nil {
+			tagged[href] = true
+		}
+	}
+	return tagged
+}
+
 /*
 "Shorthand" just means individual strings are converted into Links
 */
@@ -187,7 +354,7 @@ func getLinksShorthand(o object.Object, key string) ([]*Link, error) {
 			}
 			output[i] = link
 		case string:
-			link, err := NewLink(object.Object{
+			link, err := NewLink(map[string]any{
 				"type": "Link",
 				"href": narrowed,
 			})
@@ -210,6 +377,14 @@ func getBestLinkShorthand(o object.Object, key string, supertype string) (*Link,
 	return SelectBestLink(links, supertype)
 }
 
+func getPreferredLinkShorthand(o object.Object, key string, supertype string) (*Link, error) {
+	links, err := getLinksShorthand(o, key)
+	if err != nil {
+		return nil, err
+	}
+	return SelectPreferredLink(links, supertype)
+}
+
 func getFirstLinkShorthand(o object.Object, key string) (*Link, error) {
 	links, err := getLinksShorthand(o, key)
 	if err != nil {
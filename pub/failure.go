@@ -1,21 +1,57 @@
 package pub
 
 import (
+	"servitor/client"
 	"servitor/mime"
 	"servitor/style"
+	"net/url"
 	"time"
 	"servitor/ansi"
 )
 
 type Failure struct {
 	message error
+
+	/* What the failed item was constructed from, if it can be retried */
+	construct func(any, *url.URL) Tangible
+	input     any
+	source    *url.URL
 }
 
 func NewFailure(err error) *Failure {
 	if err == nil {
 		panic("can't create Failure with a nil error")
 	}
-	return &Failure{err}
+	return &Failure{message: err}
+}
+
+/* Constructs the item, remembering how if it fails so it can be retried */
+func constructRetryably(construct func(any, *url.URL) Tangible, input any, source *url.URL) Tangible {
+	item := construct(input, source)
+	if failure, ok := item.(*Failure); ok && failure.construct == nil {
+		failure.retryWith(construct, input, source)
+	}
+	return item
+}
+
+/* Remembers how the failed item was constructed, so that it can be retried */
+func (f *Failure) retryWith(construct func(any, *url.URL) Tangible, input any, source *url.URL) {
+	f.construct = construct
+	f.input = input
+	f.source = source
+}
+
+func (f *Failure) Retryable() bool {
+	return f.construct != nil
+}
+
+/* Constructs the item again, bypassing the cache, which makes requests */
+func (f *Failure) Retry() Tangible {
+	if f.construct == nil {
+		return f
+	}
+	client.Evict(f.input, f.source)
+	return constructRetryably(f.construct, f.input, f.source)
 }
 
 func (f *Failure) Name() string {
@@ -0,0 +1,101 @@
+package pub
+
+import (
+	"fmt"
+	"servitor/ansi"
+	"servitor/mime"
+	"servitor/object"
+	"servitor/style"
+	"net/url"
+	"strings"
+	"time"
+)
+
+/*
+Hashtags aren't ActivityStreams objects, but they appear in
+places like Mastodon's featuredTags. Their href usually serves
+a collection of the posts that use the tag.
+*/
+type Hashtag struct {
+	raw     object.Object
+	name    string
+	nameErr error
+	href    *url.URL
+	hrefErr error
+
+	posts *deferredCollection
+}
+
+func NewHashtag(input any, source *url.URL) (*Hashtag, error) {
+	asMap, ok := input.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("can't turn non-object %T into Hashtag", input)
+	}
+	o := object.Object(asMap)
+
+	kind, err := o.GetString("type")
+	if err != nil {
+		return nil, err
+	}
+	if kind != "Hashtag" {
+		return nil, fmt.Errorf("%w: %s is not a Hashtag", ErrWrongType, kind)
+	}
+
+	h := &Hashtag{}
+	h.raw = o
+	h.name, h.nameErr = o.GetString("name")
+	h.href, h.hrefErr = o.GetURL("href")
+	if h.hrefErr == nil && source != nil {
+		h.href = source.ResolveReference(h.href)
+	}
+	h.posts = getDeferredCollection(o, "href", source, NewTangible)
+	return h, nil
+}
+
+func (h *Hashtag) Name() string {
+	if h.nameErr != nil {
+		return style.Problem(h.nameErr)
+	}
+	return style.Color("#" + strings.TrimPrefix(h.name, "#"))
+}
+
+func (h *Hashtag) Preview(width int) string {
+	if h.nameErr != nil || h.hrefErr != nil {
+		return ansi.Wrap(h.Name(), width)
+	}
+	return ansi.Wrap(style.Link("#"+strings.TrimPrefix(h.name, "#"), 1, h.href.String()), width)
+}
+
+func (h *Hashtag) String(width int) string {
+	return h.Preview(width)
+}
+
+func (h *Hashtag) Parents(uint) ([]Tangible, Tangible) {
+	return []Tangible{}, nil
+}
+
+func (h *Hashtag) Children() Container {
+	if !h.posts.present() {
+		return nil
+	}
+	return h.posts
+}
+
+func (h *Hashtag) Timestamp() time.Time {
+	return time.Time{}
+}
+
+func (h *Hashtag) SelectLink(input int) (string, *mime.MediaType, bool) {
+	if input != 1 || h.hrefErr != nil {
+		return "", nil, false
+	}
+	return h.href.String(), mime.Unknown(), true
+}
+
+func constructHashtag(input any, source *url.URL) Tangible {
+	hashtag, err := NewHashtag(input, source)
+	if err != nil {
+		return NewFailure(err)
+	}
+	return hashtag
+}
@@ -0,0 +1,241 @@
+package pub
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"servitor/ansi"
+	"servitor/client"
+	"servitor/jtp"
+	"servitor/mime"
+	"servitor/object"
+	"servitor/style"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+An Inspector shows the object behind a Tangible as it was received,
+with a property per child so that large objects can be scrolled through
+*/
+type Inspector struct {
+	kind    string
+	kindErr error
+	id      *url.URL
+	idErr   error
+	entries list
+}
+
+/* A property of an inspected object, whose URLs are numbered so they can be opened */
+type property struct {
+	key   string
+	value any
+}
+
+/* Returns an Inspector for the object the item was constructed from */
+func Inspect(item Tangible) (*Inspector, error) {
+	var raw object.Object
+	switch narrowed := item.(type) {
+	case *Post:
+		raw = narrowed.raw
+	case *Actor:
+		raw = narrowed.raw
+	case *Activity:
+		raw = narrowed.raw
+	case *Hashtag:
+		raw = narrowed.raw
+	}
+	if raw == nil {
+		return nil, errors.New("item wasn't constructed from an object")
+	}
+
+	i := &Inspector{}
+	i.kind, i.kindErr = raw.GetString("type")
+	i.id, i.idErr = raw.GetURL("id")
+
+	keys := make([]string, 0, len(raw))
+	for key := range raw {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	i.entries = make(list, len(keys))
+	for index, key := range keys {
+		i.entries[index] = &property{key, raw[key]}
+	}
+	return i, nil
+}
+
+func (i *Inspector) Name() string {
+	if i.kindErr != nil {
+		return "raw object"
+	}
+	return "raw " + i.kind
+}
+
+/* Computed when drawn since whether the object is cached changes */
+func (i *Inspector) origin() string {
+	if i.idErr != nil {
+		return "embedded in another object"
+	}
+	origin, fetched := client.OriginOf(i.id)
+	if !fetched {
+		return "embedded in another object"
+	}
+	output := "fetched from " + origin.Received.String()
+	if origin.Requested.String() != origin.Received.String() {
+		output += ", redirected from " + origin.Requested.String()
+	}
+	if jtp.Cached(origin.Requested) {
+		output += " • cached"
+	} else {
+		output += " • no longer cached"
+	}
+	return output
+}
+
+func (i *Inspector) Preview(width int) string {
+	output := style.Color(i.Name())
+	if i.idErr == nil {
+		output += "\n" + style.Link(i.id.String(), 1, i.id.String())
+	}
+	output += "\n" + style.Color(i.origin())
+	return ansi.Wrap(output, width)
+}
+
+func (i *Inspector) String(width int) string {
+	return i.Preview(width)
+}
+
+func (i *Inspector) Parents(uint) ([]Tangible, Tangible) {
+	return []Tangible{}, nil
+}
+
+func (i *Inspector) Children() Container {
+	return i.entries
+}
+
+func (i *Inspector) Timestamp() time.Time {
+	return time.Time{}
+}
+
+func (i *Inspector) SelectLink(input int) (string, *mime.MediaType, bool) {
+	if input != 1 || i.idErr != nil {
+		return "", nil, false
+	}
+	return i.id.String(), mime.Unknown(), true
+}
+
+func (p *property) Name() string {
+	return p.key
+}
+
+func (p *property) render() (string, []string) {
+	links := []string{}
+	output := style.Bold(quoteJSON(p.key)) + ": " + highlightJSON(p.value, "", &links)
+	return output, links
+}
+
+func (p *property) Preview(width int) string {
+	output, _ := p.render()
+	lines := strings.Split(output, "\n")
+	for index, line := range lines {
+		/* Wrapped lines keep the indentation of the line they came from */
+		trimmed := strings.TrimLeft(line, " ")
+		indentation := line[:len(line)-len(trimmed)]
+		available := width - len(indentation) - 2
+		if available < 8 {
+			available = 8
+		}
+		lines[index] = indentation + ansi.Indent(ansi.Wrap(trimmed, available), indentation+"  ", false)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (p *property) String(width int) string {
+	return p.Preview(width)
+}
+
+func (p *property) Parents(uint) ([]Tangible, Tangible) {
+	return []Tangible{}, nil
+}
+
+func (p *property) Children() Container {
+	return nil
+}
+
+func (p *property) Timestamp() time.Time {
+	return time.Time{}
+}
+
+func (p *property) SelectLink(input int) (string, *mime.MediaType, bool) {
+	_, links := p.render()
+	if input <= 0 || input > len(links) {
+		return "", nil, false
+	}
+	return links[input-1], mime.Unknown(), true
+}
+
+/* Pretty-prints the value, appending the URLs it contains to links in the order they appear */
+func highlightJSON(value any, indentation string, links *[]string) string {
+	switch narrowed := value.(type) {
+	case map[string]any:
+		if len(narrowed) == 0 {
+			return "{}"
+		}
+		keys := make([]string, 0, len(narrowed))
+		for key := range narrowed {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		output := "{\n"
+		for index, key := range keys {
+			output += indentation + "  " + style.Bold(quoteJSON(key)) + ": " + highlightJSON(narrowed[key], indentation+"  ", links)
+			if index != len(keys)-1 {
+				output += ","
+			}
+			output += "\n"
+		}
+		return output + indentation + "}"
+	case []any:
+		if len(narrowed) == 0 {
+			return "[]"
+		}
+		output := "[\n"
+		for index, element := range narrowed {
+			output += indentation + "  " + highlightJSON(element, indentation+"  ", links)
+			if index != len(narrowed)-1 {
+				output += ","
+			}
+			output += "\n"
+		}
+		return output + indentation + "]"
+	case string:
+		if link, err := url.Parse(narrowed); err == nil && (link.Scheme == "https" || link.Scheme == "http") && link.Host != "" {
+			*links = append(*links, narrowed)
+			return style.Link(quoteJSON(narrowed), len(*links), narrowed)
+		}
+		return quoteJSON(narrowed)
+	case float64:
+		return style.Color(strconv.FormatFloat(narrowed, 'f', -1, 64))
+	case bool:
+		return style.Color(strconv.FormatBool(narrowed))
+	case nil:
+		return style.Color("null")
+	default:
+		return fmt.Sprint(narrowed)
+	}
+}
+
+func quoteJSON(text string) string {
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(text); err != nil {
+		panic(err)
+	}
+	return strings.TrimSuffix(buffer.String(), "\n")
+}
@@ -20,4 +20,6 @@ type Tangible interface {
 type Container interface {
 	/* result, index of next item, next collection */
 	Harvest(quantity uint, startingAt uint) ([]Tangible, Container, uint)
+	/* Returns the container read from its other end */
+	Reverse() (Container, error)
 }
@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"golang.org/x/exp/slices"
+	"servitor/config"
+	"servitor/graphics"
 	"servitor/mime"
 	"servitor/object"
 	"net/url"
@@ -22,6 +24,13 @@ type Link struct {
 	heightErr    error
 	width        uint64
 	widthErr     error
+	size         uint64
+	sizeErr      error
+	blurhash     string
+	blurhashErr  error
+
+	/* PeerTube nests the files of an HLS stream within its Link */
+	tags []*Link
 }
 
 func NewLink(input any) (*Link, error) {
@@ -48,18 +57,51 @@ func NewLink(input any) (*Link, error) {
 		l.uri, l.uriErr = o.GetURL("href")
 		l.height, l.heightErr = o.GetNumber("height")
 		l.width, l.widthErr = o.GetNumber("width")
+		l.size, l.sizeErr = o.GetNumber("size")
+		l.tags = getNestedLinks(o)
 	} else {
 		l.uri, l.uriErr = o.GetURL("url")
-		l.heightErr = object.ErrKeyNotPresent
-		l.widthErr = object.ErrKeyNotPresent
+		/* Mastodon gives the dimensions of attachments */
+		l.height, l.heightErr = o.GetNumber("height")
+		l.width, l.widthErr = o.GetNumber("width")
+		l.sizeErr = object.ErrKeyNotPresent
 	}
 
+	l.blurhash, l.blurhashErr = o.GetString("blurhash")
+
 	l.mediaType, l.mediaTypeErr = o.GetMediaType("mediaType")
 	l.alt, l.altErr = o.GetString("name")
 
 	return l, nil
 }
 
+/* Other kinds of tags, like PeerTube's Infohash, are skipped */
+func getNestedLinks(o object.Object) []*Link {
+	list, err := o.GetList("tag")
+	if err != nil {
+		return []*Link{}
+	}
+	links := []*Link{}
+	for _, element := range list {
+		link, err := NewLink(element)
+		if err != nil {
+			continue
+		}
+		links = append(links, link)
+	}
+	return links
+}
+
+/* Returns the links along with all of the links nested within them */
+func flattenLinks(links []*Link) []*Link {
+	output := []*Link{}
+	for _, link := range links {
+		output = append(output, link)
+		output = append(output, flattenLinks(link.tags)...)
+	}
+	return output
+}
+
 func (l *Link) Alt() (string, error) {
 	if l.altErr == nil {
 		return l.alt, nil
@@ -74,6 +116,22 @@ func (l *Link) Alt() (string, error) {
 	}
 }
 
+func (l *Link) isImage() bool {
+	return l.kind == "Image" || (l.mediaTypeErr == nil && l.mediaType.Supertype == "image")
+}
+
+/* Draws the link as an image, returning false if it can't be drawn */
+func (l *Link) draw(width int) (string, bool) {
+	if l.uriErr != nil {
+		return "", false
+	}
+	var pixelWidth, pixelHeight uint64
+	if l.widthErr == nil && l.heightErr == nil {
+		pixelWidth, pixelHeight = l.width, l.height
+	}
+	return graphics.Render(l.uri.String(), l.blurhash, pixelWidth, pixelHeight, width)
+}
+
 func (l *Link) rating() (uint64, error) {
 	var height, width uint64
 	if l.heightErr == nil {
@@ -145,6 +203,120 @@ func SelectBestLink(links []*Link, supertype string) (*Link, error) {
 	return bestLink, nil
 }
 
+/*
+Selects the link that best fits the user's preferred formats and
+maximum height, descending into nested links. Links with a matching
+supertype or a preferred format are candidates. Among those, links
+within the maximum height beat those above it, then preferred formats
+beat others, then larger links beat smaller ones (though above the
+maximum height, smaller links are better)
+*/
+func SelectPreferredLink(links []*Link, supertype string) (*Link, error) {
+	formats := config.Parsed.Media.PreferredFormats
+	maxHeight := config.Parsed.Media.PreferredMaxHeight
+
+	preference := func(l *Link) int {
+		if index := slices.Index(formats, l.mediaType.Essence); index != -1 {
+			return index
+		}
+		return len(formats)
+	}
+	fits := func(l *Link) bool {
+		return maxHeight == 0 || l.heightErr != nil || l.height <= maxHeight
+	}
+
+	candidates := []*Link{}
+	for _, link := range flattenLinks(links) {
+		if link.uriErr != nil || link.mediaTypeErr != nil {
+			continue
+		}
+		if link.mediaType.Supertype != supertype && preference(link) == len(formats) {
+			continue
+		}
+		if _, err := link.rating(); err != nil {
+			continue
+		}
+		candidates = append(candidates, link)
+	}
+
+	if len(candidates) == 0 {
+		return SelectBestLink(links, supertype)
+	}
+
+	best := candidates[0]
+	for _, this := range candidates[1:] {
+		thisRating, _ := this.rating()
+		bestRating, _ := best.rating()
+		if fits(this) != fits(best) {
+			if fits(this) {
+				best = this
+			}
+		} else if !fits(this) {
+			if thisRating < bestRating {
+				best = this
+			}
+		} else if preference(this) != preference(best) {
+			if preference(this) < preference(best) {
+				best = this
+			}
+		} else if thisRating > bestRating {
+			best = this
+		}
+	}
+
+	return best, nil
+}
+
+/* Where the link points, or nothing if that is unknown */
+func (l *Link) address() string {
+	if l.uriErr != nil {
+		return ""
+	}
+	return l.uri.String()
+}
+
+/* Summarizes the properties of the link, e.g. "720p video/mp4 (24.3 MB)" */
+func (l *Link) Description() string {
+	parts := []string{}
+	if l.heightErr == nil {
+		parts = append(parts, fmt.Sprintf("%dp", l.height))
+	}
+	if l.mediaTypeErr == nil {
+		parts = append(parts, l.mediaType.Essence)
+	} else if l.kind != "Link" {
+		parts = append(parts, strings.ToLower(l.kind))
+	}
+	if l.sizeErr == nil {
+		parts = append(parts, "("+humanizeBytes(l.size)+")")
+	}
+	if alt, err := l.Alt(); err == nil && l.altErr == nil {
+		parts = append(parts, alt)
+	}
+	if len(parts) == 0 {
+		alt, err := l.Alt()
+		if err != nil {
+			return ""
+		}
+		return alt
+	}
+	return strings.Join(parts, " ")
+}
+
+func humanizeBytes(size uint64) string {
+	const unit = 1000
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	value := float64(size) / unit
+	for _, prefix := range []string{"kB", "MB", "GB"} {
+		if value < unit {
+			return fmt.Sprintf("%.1f %s", value, prefix)
+		}
+		value /= unit
+	}
+	return fmt.Sprintf("%.1f TB", value)
+}
+
 func (l *Link) Select() (string, *mime.MediaType, bool) {
 	return l.SelectWithDefaultMediaType(mime.Unknown())
 }
@@ -6,6 +6,7 @@ import (
 	"golang.org/x/exp/slices"
 	"servitor/ansi"
 	"servitor/client"
+	"servitor/config"
 	"servitor/mime"
 	"servitor/object"
 	"servitor/style"
@@ -18,33 +19,84 @@ import (
 type Post struct {
 	kind string
 	id   *url.URL
-
-	title      string
-	titleErr   error
-	body       object.Markup
-	bodyLinks  []string
-	bodyErr    error
-	media      *Link
-	mediaErr   error
-	created    time.Time
-	createdErr error
-	edited     time.Time
-	editedErr  error
+	raw  object.Object
+
+	title     string
+	titleErr  error
+	body      object.Markup
+	bodyLinks []string
+	bodyErr   error
+	/* The rendering of the content or source that isn't shown, which ToggleSource swaps in */
+	alternate      object.Markup
+	alternateLinks []string
+	alternateErr   error
+	hasSource      bool
+	showingSource  bool
+	/* The hrefs of the post's Mention and Hashtag tags, which are opened within servitor */
+	tagged           map[string]bool
+	media            *Link
+	mediaErr         error
+	created          time.Time
+	createdErr       error
+	edited           time.Time
+	editedErr        error
 	parentObject     object.Object
 	parentIdentifier *url.URL
-	parentErr  error
+	parentErr        error
 
 	// just as body dies completely if members die,
 	// attachments dies completely if any member dies
 	attachments    []*Link
 	attachmentsErr error
 
-	creators    []Tangible
-	recipients  []Tangible
+	creators   []Tangible
+	recipients []Tangible
+
+	/* The addresses under to, cc, bto, and bcc */
+	addresses     map[string][]string
+	addressesErr  error
+	visibility    string
+	visibilityErr error
+
 	comments    *Collection
 	commentsErr error
+	likes       *deferredCollection
+	dislikes    *deferredCollection
+	shares      *deferredCollection
+	context     *deferredCollection
+
+	quoteReference any
+	quote          *Post
+	quoteErr       error
+
+	/* Set when the post is listed among an actor's featured posts */
+	pinned bool
+
+	/* Whether images are drawn inline rather than only linked */
+	showImages bool
+
+	/* The version of the post seen before this one, if it has changed during the session */
+	previous *version
+
+	/* Metadata that PeerTube provides for videos */
+	duration     time.Duration
+	durationErr  error
+	views        uint64
+	viewsErr     error
+	category     string
+	categoryErr  error
+	license      string
+	licenseErr   error
+	language     string
+	languageErr  error
+	streams      []*Link
+	subtitles    []*Link
+	subtitlesErr error
 }
 
+/* The number of nested quotes that will be fetched and displayed */
+const maxQuoteDepth = 2
+
 func NewPost(input any, source *url.URL) (*Post, error) {
 	o, id, err := client.FetchUnknown(input, source)
 	if err != nil {
@@ -54,8 +106,18 @@ func NewPost(input any, source *url.URL) (*Post, error) {
 }
 
 func NewPostFromObject(o object.Object, id *url.URL) (*Post, error) {
+	return newPostFromObject(o, id, []string{})
+}
+
+/*
+quoters holds the identifiers of the posts that (transitively) quote
+this one, which is used to avoid following quote cycles
+*/
+func newPostFromObject(o object.Object, id *url.URL, quoters []string) (*Post, error) {
 	p := &Post{}
 	p.id = id
+	p.raw = o
+	p.showImages = config.Parsed.Media.InlineImages
 	var err error
 	if p.kind, err = o.GetString("type"); err != nil {
 		return nil, err
@@ -72,19 +134,65 @@ func NewPostFromObject(o object.Object, id *url.URL) (*Post, error) {
 	}
 
 	p.title, p.titleErr = o.GetString("name")
-	p.body, p.bodyLinks, p.bodyErr = o.GetMarkup("content", "mediaType")
+	p.body, p.bodyLinks, p.bodyErr = o.GetMarkup("content", "mediaType", p.id)
+	p.alternate, p.alternateLinks, p.alternateErr = getSource(o, p.id)
+	p.hasSource = p.alternateErr == nil
+	if config.Parsed.Style.PreferSource {
+		p.ToggleSource()
+	}
+	p.tagged = getTagged(o)
 	p.created, p.createdErr = o.GetTime("published")
 	p.edited, p.editedErr = o.GetTime("updated")
 	p.parentObject, p.parentIdentifier, p.parentErr = getAndFetchUnkown(o, "inReplyTo", p.id)
 
-	if p.kind == "Audio" || p.kind == "Video" || p.kind == "Image" {
+	if p.kind == "Audio" || p.kind == "Video" {
+		p.media, p.mediaErr = getPreferredLinkShorthand(o, "url", strings.ToLower(p.kind))
+	} else if p.kind == "Image" {
 		p.media, p.mediaErr = getBestLinkShorthand(o, "url", strings.ToLower(p.kind))
 	} else {
 		p.media, p.mediaErr = getFirstLinkShorthand(o, "url")
 	}
 
+	if links, err := getLinksShorthand(o, "url"); err == nil {
+		p.streams = flattenLinks(links)
+	}
+	p.subtitles, p.subtitlesErr = getSubtitles(o)
+	p.duration, p.durationErr = o.GetDuration("duration")
+	p.views, p.viewsErr = o.GetNumber("views")
+	p.category, p.categoryErr = getPropertyName(o, "category")
+	p.license, p.licenseErr = getPropertyName(o, "licence")
+	if errors.Is(p.licenseErr, object.ErrKeyNotPresent) {
+		p.license, p.licenseErr = getPropertyName(o, "license")
+	}
+	p.language, p.languageErr = getPropertyName(o, "language")
+
+	p.quoteReference, p.quoteErr = getQuoteReference(o)
+	p.addresses, p.addressesErr = getAddresses(o, p.id)
+
+	constructActivity := func(input any, source *url.URL) Tangible {
+		activity, err := NewActivity(input, source)
+		if err != nil {
+			return NewFailure(err)
+		}
+		return activity
+	}
+	p.likes = getDeferredCollection(o, "likes", p.id, constructActivity)
+	p.dislikes = getDeferredCollection(o, "dislikes", p.id, constructActivity)
+	p.shares = getDeferredCollection(o, "shares", p.id, constructActivity)
+
+	/* Per FEP-7888, context may be a collection of every object in the
+	   conversation, which are sometimes wrapped in their Create activities */
+	constructContextItem := func(input any, source *url.URL) Tangible {
+		item := NewTangible(input, source)
+		if activity, ok := item.(*Activity); ok && activity.kind == "Create" {
+			return activity.Target()
+		}
+		return item
+	}
+	p.context = getDeferredCollection(o, "context", p.id, constructContextItem)
+
 	var wg sync.WaitGroup
-	wg.Add(4)
+	wg.Add(5)
 	go func() { p.creators = getActors(o, "attributedTo", p.id); wg.Done() }()
 	go func() { p.recipients = getActors(o, "audience", p.id); wg.Done() }()
 	go func() { p.attachments, p.attachmentsErr = getLinks(o, "attachment"); wg.Done() }()
@@ -113,8 +221,20 @@ func NewPostFromObject(o object.Object, id *url.URL) (*Post, error) {
 		}
 		wg.Done()
 	}()
+	go func() {
+		if p.quoteErr == nil {
+			p.quote, p.quoteErr = p.fetchQuote(quoters)
+		}
+		wg.Done()
+	}()
 	wg.Wait()
 
+	p.visibility, p.visibilityErr = p.computeVisibility()
+
+	if content, err := o.GetString("content"); err == nil {
+		p.previous = recordVersion(p, content)
+	}
+
 	/* Ensure that creators come from the same host as the post itself */
 	for _, creator := range p.creators {
 		if asActor, isActor := creator.(*Actor); isActor {
@@ -132,6 +252,117 @@ func NewPostFromObject(o object.Object, id *url.URL) (*Post, error) {
 	return p, nil
 }
 
+/*
+Quote posts reference the quoted post in one of several ways:
+  - quote, per FEP-044f and newer Mastodon
+  - quoteUrl, used by Misskey and Akkoma
+  - quoteUri, used by Fedibird
+  - _misskey_quote, used by Misskey
+  - a Link tag with an ActivityStreams media type, per FEP-e232
+*/
+func getQuoteReference(o object.Object) (any, error) {
+	for _, key := range []string{"quote", "quoteUrl", "quoteUri", "_misskey_quote"} {
+		reference, err := o.GetAny(key)
+		if errors.Is(err, object.ErrKeyNotPresent) {
+			continue
+		}
+		return reference, err
+	}
+
+	/* Problems with the tags are not problems with the quote, so they are ignored */
+	tags, err := o.GetList("tag")
+	if err != nil {
+		return nil, object.ErrKeyNotPresent
+	}
+	for _, element := range tags {
+		asMap, ok := element.(map[string]any)
+		if !ok {
+			continue
+		}
+		tag := object.Object(asMap)
+		if kind, err := tag.GetString("type"); err != nil || kind != "Link" {
+			continue
+		}
+		mediaType, err := tag.GetMediaType("mediaType")
+		if err != nil || !mediaType.Matches([]string{
+			"application/activity+json",
+			"application/ld+json",
+		}) {
+			continue
+		}
+		if href, err := tag.GetString("href"); err == nil {
+			return href, nil
+		}
+	}
+
+	return nil, object.ErrKeyNotPresent
+}
+
+func (p *Post) fetchQuote(quoters []string) (*Post, error) {
+	if len(quoters) >= maxQuoteDepth {
+		return nil, errQuoteTooDeep
+	}
+
+	o, id, err := client.FetchUnknown(p.quoteReference, p.id)
+	if err != nil {
+		return nil, err
+	}
+
+	if id != nil && (slices.Contains(quoters, id.String()) || (p.id != nil && p.id.String() == id.String())) {
+		return nil, errQuoteCycle
+	}
+
+	/* Cloned so sibling quotes don't share a backing array */
+	quoters = slices.Clone(quoters)
+	if p.id != nil {
+		quoters = append(quoters, p.id.String())
+	} else {
+		quoters = append(quoters, "")
+	}
+
+	return newPostFromObject(o, id, quoters)
+}
+
+/* PeerTube represents properties like categories as {"identifier": …, "name": …} */
+func getPropertyName(o object.Object, key string) (string, error) {
+	property, err := o.GetObject(key)
+	if err != nil {
+		return "", err
+	}
+	return object.Object(property).GetString("name")
+}
+
+/*
+PeerTube lists subtitles as {"name": …, "url": …}, where
+the url is either a string or a list of Links
+*/
+func getSubtitles(o object.Object) ([]*Link, error) {
+	list, err := o.GetList("subtitleLanguage")
+	if err != nil {
+		return nil, err
+	}
+	subtitles := []*Link{}
+	for _, element := range list {
+		asMap, ok := element.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("subtitle is a %T rather than an object", element)
+		}
+		entry := object.Object(asMap)
+		name, nameErr := entry.GetString("name")
+		links, err := getLinksShorthand(entry, "url")
+		if err != nil {
+			return nil, err
+		}
+		for _, link := range links {
+			if link.altErr != nil {
+				link.alt, link.altErr = name, nameErr
+			}
+		}
+		subtitles = append(subtitles, links...)
+	}
+	return subtitles, nil
+}
+
 func (p *Post) Children() Container {
 	/* the if is necessary because my understanding is
 	the first nil is a (*Collection)(nil) whereas
@@ -154,7 +385,16 @@ func (p *Post) Parents(quantity uint) ([]Tangible, Tangible) {
 		return []Tangible{}, nil
 	}
 	if p.parentErr != nil {
-		return []Tangible{NewFailure(p.parentErr)}, nil
+		failure := NewFailure(p.parentErr)
+		reference, _ := p.raw.GetAny("inReplyTo")
+		failure.retryWith(func(input any, source *url.URL) Tangible {
+			parent, err := NewPost(input, source)
+			if err != nil {
+				return NewFailure(err)
+			}
+			return parent
+		}, reference, p.id)
+		return []Tangible{failure}, nil
 	}
 	parent, err := NewPostFromObject(p.parentObject, p.parentIdentifier)
 	if err != nil {
@@ -167,6 +407,22 @@ func (p *Post) Parents(quantity uint) ([]Tangible, Tangible) {
 	return append([]Tangible{parent}, parentParents...), parentFrontier
 }
 
+/*
+Returns the collection of every post in the conversation, if the
+post has a context; the context may not be a collection at all, in
+which case harvesting it produces a Failure
+*/
+func (p *Post) Context() Container {
+	if !p.context.present() {
+		return nil
+	}
+	return p.context
+}
+
+func (p *Post) Identifier() *url.URL {
+	return p.id
+}
+
 func (p *Post) ParentIdentifier() *url.URL {
 	if p.parentErr != nil {
 		return nil
@@ -216,10 +472,84 @@ func (p *Post) header(width int) string {
 		output += " • " + style.Color(ago(p.created))
 	}
 
+	if p.editedErr == nil && (p.createdErr != nil || p.edited.After(p.created)) {
+		output += " • " + style.Color("edited "+ago(p.edited))
+	} else if p.editedErr != nil && !errors.Is(p.editedErr, object.ErrKeyNotPresent) {
+		output += " • edited at " + style.Problem(p.editedErr)
+	}
+	if p.previous != nil {
+		output += " • " + style.Color("changed since it was first loaded")
+	}
+
+	if errors.Is(p.visibilityErr, object.ErrKeyNotPresent) {
+		// omit it
+	} else if p.visibilityErr != nil {
+		output += " • " + style.Problem(p.visibilityErr)
+	} else {
+		output += " • " + style.Color(p.visibility)
+	}
+
+	if p.pinned {
+		output += " • " + style.Color("pinned")
+	}
+
+	if details := p.details(); details != "" {
+		output += "\n" + details
+	}
+
 	return ansi.Wrap(output, width)
 }
 
-func (p *Post) center(width int) (string, bool) {
+/* Summarizes metadata like the duration and view count */
+func (p *Post) details() string {
+	parts := []string{}
+
+	if p.durationErr == nil {
+		parts = append(parts, style.Color(formatDuration(p.duration)))
+	} else if !errors.Is(p.durationErr, object.ErrKeyNotPresent) {
+		parts = append(parts, style.Problem(p.durationErr))
+	}
+
+	if p.viewsErr == nil && p.views == 1 {
+		parts = append(parts, style.Color("1 view"))
+	} else if p.viewsErr == nil {
+		parts = append(parts, style.Color(fmt.Sprintf("%d views", p.views)))
+	} else if !errors.Is(p.viewsErr, object.ErrKeyNotPresent) {
+		parts = append(parts, style.Problem(p.viewsErr))
+	}
+
+	for _, property := range []struct {
+		value string
+		err   error
+	}{
+		{p.category, p.categoryErr},
+		{p.language, p.languageErr},
+		{p.license, p.licenseErr},
+	} {
+		if property.err == nil {
+			parts = append(parts, style.Color(property.value))
+		} else if !errors.Is(property.err, object.ErrKeyNotPresent) {
+			parts = append(parts, style.Problem(property.err))
+		}
+	}
+
+	return strings.Join(parts, " • ")
+}
+
+func formatDuration(duration time.Duration) string {
+	seconds := int(duration.Seconds())
+	if seconds >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds%3600/60, seconds%60)
+	}
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
+
+/* Markup that can draw its images, namely HTML */
+type imageMarkup interface {
+	RenderWithImages(width int) string
+}
+
+func (p *Post) center(width int, images bool) (string, bool) {
 	if errors.Is(p.bodyErr, object.ErrKeyNotPresent) {
 		return "", false
 	}
@@ -227,11 +557,14 @@ func (p *Post) center(width int) (string, bool) {
 		return ansi.Wrap(style.Problem(p.bodyErr), width), true
 	}
 
+	if markup, ok := p.body.(imageMarkup); ok && images {
+		return markup.RenderWithImages(width), true
+	}
 	rendered := p.body.Render(width)
 	return rendered, true
 }
 
-func (p *Post) supplement(width int) (string, bool) {
+func (p *Post) supplement(width int, images bool) (string, bool) {
 	if errors.Is(p.attachmentsErr, object.ErrKeyNotPresent) {
 		return "", false
 	}
@@ -248,17 +581,135 @@ func (p *Post) supplement(width int) (string, bool) {
 		if output != "" {
 			output += "\n"
 		}
+		if images && attachment.isImage() {
+			if image, drawn := attachment.draw(width); drawn {
+				output += image + "\n"
+			}
+		}
 		alt, err := attachment.Alt()
 		if err != nil {
 			output += style.Problem(err)
 			continue
 		}
-		output += style.LinkBlock(ansi.Wrap(alt, width-2), len(p.bodyLinks)+i+1)
+		output += style.LinkBlock(ansi.Wrap(alt, width-2), len(p.bodyLinks)+i+1, attachment.address())
 	}
 	return output, true
 }
 
+/* Draws the image of an Image post, which otherwise is only opened as media */
+func (p *Post) picture(width int) (string, bool) {
+	if p.kind != "Image" || p.mediaErr != nil {
+		return "", false
+	}
+	return p.media.draw(width)
+}
+
+/*
+Previews are cut short, so they draw their images after the cut
+without the alt text, which is in the part above
+*/
+func (p *Post) previewImages(width int) (string, bool) {
+	images := []string{}
+	if picture, drawn := p.picture(width); drawn {
+		images = append(images, picture)
+	}
+	if p.attachmentsErr == nil {
+		for _, attachment := range p.attachments {
+			if !attachment.isImage() {
+				continue
+			}
+			if image, drawn := attachment.draw(width); drawn {
+				images = append(images, image)
+			}
+		}
+	}
+	return strings.Join(images, "\n"), len(images) != 0
+}
+
+/* Switches between drawing images inline and only linking them */
+func (p *Post) ToggleImages() {
+	p.showImages = !p.showImages
+}
+
+/* Expands or collapses any details in the body, returning false if there are none */
+func (p *Post) ToggleDetails() bool {
+	if p.bodyErr != nil {
+		return false
+	}
+	if body, ok := p.body.(interface{ ToggleDetails() bool }); ok {
+		return body.ToggleDetails()
+	}
+	return false
+}
+
+/* Switches between rendering the content and the source, returning false if there is no source */
+func (p *Post) ToggleSource() bool {
+	if !p.hasSource {
+		return false
+	}
+	p.body, p.alternate = p.alternate, p.body
+	p.bodyLinks, p.alternateLinks = p.alternateLinks, p.bodyLinks
+	p.bodyErr, p.alternateErr = p.alternateErr, p.bodyErr
+	p.showingSource = !p.showingSource
+	return true
+}
+
+/* The rendering of the content, whether or not it is the one shown; versions are always of the content */
+func (p *Post) content() (object.Markup, error) {
+	if p.showingSource {
+		return p.alternate, p.alternateErr
+	}
+	return p.body, p.bodyErr
+}
+
+/* Fetches the likes, dislikes and shares so the footer can count them, which makes requests */
+func (p *Post) Prefetch() {
+	var wg sync.WaitGroup
+	for _, collection := range []*deferredCollection{p.likes, p.dislikes, p.shares} {
+		if !collection.present() {
+			continue
+		}
+		collection := collection
+		wg.Add(1)
+		go func() { collection.fetch(); wg.Done() }()
+	}
+	wg.Wait()
+}
+
 func (p *Post) footer(width int) string {
+	output := p.commentCount()
+
+	/* Software with downvotes (e.g. PeerTube) treats likes as upvotes */
+	likes, dislikes := "like", "dislike"
+	if p.dislikes.present() {
+		likes, dislikes = "upvote", "downvote"
+	}
+	for _, tally := range []struct {
+		collection *deferredCollection
+		noun       string
+	}{
+		{p.likes, likes},
+		{p.dislikes, dislikes},
+		{p.shares, "share"},
+	} {
+		if !tally.collection.present() {
+			continue
+		}
+		/* Until the collection is fetched, or if it has no totalItems, the count is unknown */
+		quantity, known := tally.collection.size()
+		if !known {
+			output += " • " + style.Color("? "+tally.noun+"s")
+		} else if quantity == 1 {
+			output += " • " + style.Color(fmt.Sprintf("%d %s", quantity, tally.noun))
+		} else {
+			output += " • " + style.Color(fmt.Sprintf("%d %ss", quantity, tally.noun))
+		}
+	}
+
+	return ansi.Wrap(output, width)
+}
+
+func (p *Post) commentCount() string {
 	if errors.Is(p.commentsErr, object.ErrKeyNotPresent) {
 		return style.Color("comments disabled")
 	} else if p.commentsErr != nil {
@@ -274,17 +725,46 @@ func (p *Post) footer(width int) string {
 	}
 }
 
+func (p *Post) quotation(width int) (string, bool) {
+	if errors.Is(p.quoteErr, object.ErrKeyNotPresent) {
+		return "", false
+	}
+
+	var quoted string
+	if errors.Is(p.quoteErr, errQuoteTooDeep) {
+		quoted = ansi.Wrap(style.Color("quotes another post"), width-4)
+	} else if errors.Is(p.quoteErr, errQuoteCycle) {
+		quoted = ansi.Wrap(style.Color("quotes a post shown above"), width-4)
+	} else if p.quoteErr != nil {
+		quoted = ansi.Wrap(style.Problem(fmt.Errorf("failed to load quote: %w", p.quoteErr)), width-4)
+	} else {
+		quoted = p.quote.Preview(width - 4)
+	}
+
+	return style.Frame(quoted, width), true
+}
+
 func (p Post) String(width int) string {
 	output := p.header(width)
 
-	if body, present := p.center(width - 4); present {
+	if p.showImages {
+		if picture, drawn := p.picture(width - 4); drawn {
+			output += "\n\n" + ansi.Indent(picture, "  ", true)
+		}
+	}
+
+	if body, present := p.center(width-4, p.showImages); present {
 		output += "\n\n" + ansi.Indent(body, "  ", true)
 	}
 
-	if attachments, present := p.supplement(width - 4); present {
+	if attachments, present := p.supplement(width-4, p.showImages); present {
 		output += "\n\n" + ansi.Indent(attachments, "  ", true)
 	}
 
+	if quote, present := p.quotation(width - 4); present {
+		output += "\n\n" + ansi.Indent(quote, "  ", true)
+	}
+
 	output += "\n\n" + p.footer(width)
 
 	return output
@@ -293,19 +773,31 @@ func (p Post) String(width int) string {
 func (p *Post) Preview(width int) string {
 	output := p.header(width)
 
-	body, bodyPresent := p.center(width)
+	body, bodyPresent := p.center(width, false)
 	if bodyPresent {
 		output += "\n" + body
 	}
 
-	if attachments, present := p.supplement(width); present {
+	if attachments, present := p.supplement(width, false); present {
 		if bodyPresent {
 			output += "\n"
 		}
 		output += "\n" + attachments
 	}
 
-	return ansi.Snip(output, width, 4, style.Color("\u2026"))
+	output = ansi.Snip(output, width, 4, style.Color("\u2026"))
+
+	if p.showImages {
+		if images, present := p.previewImages(width); present {
+			output += "\n" + images
+		}
+	}
+
+	if quote, present := p.quotation(width); present {
+		output += "\n" + quote
+	}
+
+	return output
 }
 
 func (p *Post) Timestamp() time.Time {
@@ -343,10 +835,74 @@ func (p *Post) Media() (string, *mime.MediaType, bool) {
 	return p.media.Select()
 }
 
+/*
+Returns the address of the quoted post, if there is one, so
+it can be opened in full
+*/
+func (p *Post) Quote() (string, bool) {
+	if p.quote != nil && p.quote.id != nil {
+		return p.quote.id.String(), true
+	}
+
+	reference, ok := p.quoteReference.(string)
+	if !ok {
+		return "", false
+	}
+	address, err := url.Parse(reference)
+	if err != nil {
+		return "", false
+	}
+	if p.id != nil {
+		address = p.id.ResolveReference(address)
+	}
+	return address.String(), true
+}
+
+/* Returns every stream and subtitle track of the post, if there are multiple */
+func (p *Post) Streams() (*Streams, bool) {
+	if len(p.streams) < 2 && len(p.subtitles) == 0 {
+		return nil, false
+	}
+	name := ""
+	if p.titleErr == nil {
+		name = p.title
+	}
+	return &Streams{
+		name:      name,
+		streams:   p.streams,
+		subtitles: p.subtitles,
+	}, true
+}
+
+/*
+Mentions and hashtags are ActivityPub objects, while other links are
+of unknown type, likely web pages. The markup's classes are used, as
+well as the tags, since not all software marks up mentions the same way
+*/
+func (p *Post) bodyLinkMediaType(index int) *mime.MediaType {
+	if p.tagged[p.bodyLinks[index]] {
+		return mime.ActivityPub()
+	}
+	if markup, ok := p.body.(interface{ IsMentionOrHashtag(int) bool }); ok && markup.IsMentionOrHashtag(index) {
+		return mime.ActivityPub()
+	}
+	return mime.Unknown()
+}
+
+/* Returns the likes collection (or a Failure), which may require a request */
+func (p *Post) Likes() (any, bool) {
+	return p.likes.open()
+}
+
+/* Returns the shares collection (or a Failure), which may require a request */
+func (p *Post) Shares() (any, bool) {
+	return p.shares.open()
+}
+
 func (p *Post) SelectLink(input int) (string, *mime.MediaType, bool) {
 	input -= 1
 	if len(p.bodyLinks) > input {
-		return p.bodyLinks[input], mime.Unknown(), true
+		return p.bodyLinks[input], p.bodyLinkMediaType(input), true
 	}
 	nextIndex := input - len(p.bodyLinks)
 	if len(p.attachments) > nextIndex {
@@ -0,0 +1,164 @@
+package pub
+
+import (
+	"errors"
+	"servitor/ansi"
+	"servitor/client"
+	"servitor/diff"
+	"servitor/mime"
+	"servitor/object"
+	"servitor/style"
+	"sync"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+)
+
+/*
+	Servers don't keep the earlier versions of edited posts, so the versions
+	seen during the session are remembered instead. A post is seen again
+	whenever it falls out of the cache and is refetched, or when it is
+	refetched deliberately to check it for edits.
+*/
+
+type version struct {
+	/* When the version was published or last edited */
+	edited  time.Time
+	content string
+	body    object.Markup
+}
+
+/* The number of posts whose versions are remembered */
+const maxTrackedPosts = 1024
+
+/* The number of versions remembered of each post */
+const maxVersions = 8
+
+var (
+	versions, _ = lru.New[string, []*version](maxTrackedPosts)
+	versionsM   sync.Mutex
+)
+
+/*
+Remembers the post's content if it differs from the last version seen,
+returning the version before it, if there is one
+*/
+func recordVersion(p *Post, content string) *version {
+	body, err := p.content()
+	if p.id == nil || err != nil {
+		return nil
+	}
+	edited := p.created
+	if p.editedErr == nil {
+		edited = p.edited
+	}
+
+	versionsM.Lock()
+	defer versionsM.Unlock()
+	seen, _ := versions.Get(p.id.String())
+	for i := len(seen) - 1; i >= 0; i-- {
+		if seen[i].content == content {
+			if i == 0 {
+				return nil
+			}
+			return seen[i-1]
+		}
+	}
+	seen = append(seen, &version{edited, content, body})
+	if len(seen) > maxVersions {
+		seen = seen[len(seen)-maxVersions:]
+	}
+	versions.Add(p.id.String(), seen)
+	if len(seen) == 1 {
+		return nil
+	}
+	return seen[len(seen)-2]
+}
+
+/* A Revision shows how the body of a post changed between two versions */
+type Revision struct {
+	post   *Post
+	before *version
+	after  *version
+}
+
+/*
+Fetches the post again, bypassing the cache, and compares it with
+the version before it, which makes requests
+*/
+func (p *Post) Revise() (*Post, *Revision, error) {
+	if p.id == nil {
+		return nil, nil, errors.New("the post has no identifier to fetch it again with")
+	}
+	client.Evict(p.id.String(), nil)
+	fetched, err := NewPost(p.id.String(), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	if fetched.previous == nil {
+		return fetched, nil, errors.New("no earlier version of the post has been seen")
+	}
+	content, _ := fetched.raw.GetString("content")
+	body, _ := fetched.content()
+	edited := fetched.created
+	if fetched.editedErr == nil {
+		edited = fetched.edited
+	}
+	return fetched, &Revision{
+		post:   fetched,
+		before: fetched.previous,
+		after:  &version{edited, content, body},
+	}, nil
+}
+
+/* Wide enough that the text is only broken where the markup breaks it */
+const unwrappedWidth = 1000
+
+func (r *Revision) Name() string {
+	return "edits"
+}
+
+func (r *Revision) String(width int) string {
+	output := style.Color("edits to " + describe(r.post))
+	output += " • " + style.Color(ago(r.before.edited)+" → "+ago(r.after.edited))
+	output = ansi.Wrap(output, width)
+
+	before := ansi.Scrub(r.before.body.Render(unwrappedWidth))
+	after := ansi.Scrub(r.after.body.Render(unwrappedWidth))
+	body := ""
+	for _, piece := range diff.Words(before, after) {
+		switch piece.Kind {
+		case diff.Same:
+			body += piece.Text
+		case diff.Removed:
+			body += style.Red(style.Strikethrough(piece.Text))
+		case diff.Added:
+			body += style.Highlight(piece.Text)
+		}
+	}
+	return output + "\n\n" + ansi.Indent(ansi.Wrap(body, width-4), "  ", true)
+}
+
+func (r *Revision) Preview(width int) string {
+	return r.String(width)
+}
+
+/* The post is shown above the edits, as it is now */
+func (r *Revision) Parents(quantity uint) ([]Tangible, Tangible) {
+	if quantity == 0 {
+		return []Tangible{}, r
+	}
+	return []Tangible{r.post}, nil
+}
+
+func (r *Revision) Children() Container {
+	return nil
+}
+
+func (r *Revision) Timestamp() time.Time {
+	return r.after.edited
+}
+
+func (r *Revision) SelectLink(input int) (string, *mime.MediaType, bool) {
+	return "", nil, false
+}
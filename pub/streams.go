@@ -0,0 +1,75 @@
+package pub
+
+import (
+	"servitor/ansi"
+	"servitor/mime"
+	"servitor/style"
+	"strings"
+	"time"
+)
+
+/*
+Lists every stream and subtitle track of a post so
+one other than the automatically selected one can be opened
+*/
+type Streams struct {
+	name      string
+	streams   []*Link
+	subtitles []*Link
+}
+
+func (s *Streams) Name() string {
+	return s.name
+}
+
+func (s *Streams) Preview(width int) string {
+	output := style.Color("streams")
+	if s.name != "" {
+		output += style.Color(" of ") + s.name
+	}
+	output = ansi.Wrap(output, width)
+
+	for i, link := range s.streams {
+		output += "\n" + style.LinkBlock(ansi.Wrap(link.Description(), width-2), i+1, link.address())
+	}
+
+	if len(s.subtitles) != 0 {
+		output += "\n\n" + style.Color("subtitles")
+		for i, link := range s.subtitles {
+			output += "\n" + style.LinkBlock(ansi.Wrap(link.Description(), width-2), len(s.streams)+i+1, link.address())
+		}
+	}
+
+	return strings.TrimSuffix(output, "\n")
+}
+
+func (s *Streams) String(width int) string {
+	return s.Preview(width)
+}
+
+func (s *Streams) Parents(uint) ([]Tangible, Tangible) {
+	return []Tangible{}, nil
+}
+
+func (s *Streams) Children() Container {
+	return nil
+}
+
+func (s *Streams) Timestamp() time.Time {
+	return time.Time{}
+}
+
+func (s *Streams) SelectLink(input int) (string, *mime.MediaType, bool) {
+	input -= 1
+	if input < 0 {
+		return "", nil, false
+	}
+	if input < len(s.streams) {
+		return s.streams[input].Select()
+	}
+	input -= len(s.streams)
+	if input < len(s.subtitles) {
+		return s.subtitles[input].SelectWithDefaultMediaType(mime.UnknownSubtype("text"))
+	}
+	return "", nil, false
+}
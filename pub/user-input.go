@@ -1,11 +1,28 @@
 package pub
 
 import (
+	"errors"
+	"net/url"
 	"servitor/client"
 	"strings"
 )
 
+/* Failures are retried from the text, so that handles are resolved again */
 func FetchUserInput(text string) Any {
+	result := fetchUserInput(text)
+	if failure, ok := result.(*Failure); ok {
+		failure.retryWith(func(input any, _ *url.URL) Tangible {
+			retried := fetchUserInput(input.(string))
+			if tangible, ok := retried.(Tangible); ok {
+				return tangible
+			}
+			return NewFailure(errors.New("item is a collection"))
+		}, text, nil)
+	}
+	return result
+}
+
+func fetchUserInput(text string) Any {
 	if strings.HasPrefix(text, "@") || strings.HasPrefix(text, "!") {
 		link, err := client.ResolveWebfinger(text[1:])
 		if err != nil {
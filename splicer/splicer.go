@@ -1,6 +1,9 @@
 package splicer
 
 import (
+	"errors"
+	"golang.org/x/exp/slices"
+	"servitor/config"
 	"servitor/pub"
 	"sync"
 )
@@ -34,6 +37,11 @@ func (s Splicer) Harvest(quantity uint, startingPoint uint) ([]pub.Tangible, pub
 	return output, clone, 0
 }
 
+/* Splicing always takes the most recent item first, so it can't read sources from their end */
+func (s Splicer) Reverse() (pub.Container, error) {
+	return nil, errors.New("feeds can't be read from their end")
+}
+
 func (s Splicer) clone() *Splicer {
 	newSplicer := make(Splicer, len(s))
 	copy(newSplicer, s)
@@ -45,15 +53,22 @@ func (s Splicer) clone() *Splicer {
 
 func (s Splicer) replenish(amount int) {
 	var wg sync.WaitGroup
-	for i, source := range s {
+	for i := range s {
 		i := i
-		source := source
 		wg.Add(1)
 		go func() {
-			if len(source.elements) < amount && source.page != nil {
+			/* Items that are filtered out can leave a source short, so harvest until it has enough */
+			for len(s[i].elements) < amount && s[i].page != nil {
 				var newElements []pub.Tangible
-				newElements, s[i].page, s[i].basepoint = source.page.Harvest(uint(amount - len(source.elements)), source.basepoint)
-				s[i].elements = append(s[i].elements, newElements...)
+				newElements, s[i].page, s[i].basepoint = s[i].page.Harvest(uint(amount - len(s[i].elements)), s[i].basepoint)
+				if len(newElements) == 0 {
+					break
+				}
+				for _, element := range newElements {
+					if shown(element) {
+						s[i].elements = append(s[i].elements, element)
+					}
+				}
 			}
 			wg.Done()
 		}()
@@ -61,6 +76,19 @@ func (s Splicer) replenish(amount int) {
 	wg.Wait()
 }
 
+/*
+Whether the item is of a kind of activity the config includes in feeds;
+activities relayed by groups are judged by the activity they relay
+*/
+func shown(item pub.Tangible) bool {
+	activity, ok := item.(*pub.Activity)
+	if !ok {
+		return true
+	}
+	verbs := activity.Verbs()
+	return slices.Contains(config.Parsed.Splicing.Activities, verbs[len(verbs)-1])
+}
+
 func (s Splicer) microharvest() pub.Tangible {
 	var mostRecent pub.Tangible
 	var mostRecentIndex int
@@ -71,6 +99,13 @@ func (s Splicer) microharvest() pub.Tangible {
 
 		candidateElement := candidate.elements[0]
 
+		/* Failures have no timestamp, so they are taken as soon as they're reached rather than holding back their source */
+		if _, failed := candidateElement.(*pub.Failure); failed {
+			mostRecent = candidateElement
+			mostRecentIndex = i
+			break
+		}
+
 		if mostRecent == nil {
 			mostRecent = candidateElement
 			mostRecentIndex = i
@@ -107,7 +142,15 @@ func NewSplicer(inputs []string) *Splicer {
 		wg.Add(1)
 		go func() {
 			fetched := pub.FetchUserInput(input)
+			s[i].basepoint = 0
+			s[i].elements = []pub.Tangible{}
 			switch narrowed := fetched.(type) {
+			case *pub.Failure:
+				/* Shown in the feed, where it can be retried */
+				s[i].elements = []pub.Tangible{narrowed}
+			case *pub.Actor:
+				/* Pinned posts are omitted because they are out of chronological order */
+				s[i].page = narrowed.Posts()
 			case pub.Tangible:
 				s[i].page = narrowed.Children()
 			case *pub.Collection:
@@ -115,8 +158,6 @@ func NewSplicer(inputs []string) *Splicer {
 			default:
 				panic("cannot splice non-Tangible, non-Collection")
 			}
-			s[i].basepoint = 0
-			s[i].elements = []pub.Tangible{}
 			wg.Done()
 		}()
 	}
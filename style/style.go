@@ -5,6 +5,7 @@ import (
 	"strconv"
 	"strings"
 	"servitor/config"
+	"servitor/syntax"
 )
 
 func background(text string, rgb string) string {
@@ -53,21 +54,78 @@ func Red(text string) string {
 	return foreground(text, config.Parsed.Style.Colors.Error)
 }
 
-func Link(text string, number int) string {
-	return Color(Underline(text) + superscript(number))
+/* Links are numbered, and with hyperlinks enabled are also clickable when `url` is known */
+func Link(text string, number int, url string) string {
+	return hyperlink(Color(Underline(text)+superscript(number)), url)
+}
+
+/* Mentions and hashtags lead to other parts of the fediverse rather than the web, so they aren't underlined */
+func Mention(text string, number int, url string) string {
+	return hyperlink(Color(Bold(text)+superscript(number)), url)
+}
+
+func hyperlink(text string, url string) string {
+	if !config.Parsed.Style.Hyperlinks || url == "" {
+		return text
+	}
+	return ansi.Hyperlink(text, url)
 }
 
 func CodeBlock(text string) string {
 	return Code(text)
 }
 
+/* Colours the tokens of `code`, leaving it as it is if the language is unknown */
+func Syntax(code string, language string) string {
+	tokens, ok := syntax.Tokenize(code, language)
+	if !ok {
+		return code
+	}
+	colors := config.Parsed.Style.Syntax
+	output := ""
+	for _, token := range tokens {
+		switch token.Kind {
+		case syntax.Keyword:
+			output += foreground(token.Text, colors.Keyword)
+		case syntax.Type:
+			output += foreground(token.Text, colors.Type)
+		case syntax.String:
+			output += foreground(token.Text, colors.String)
+		case syntax.Number:
+			output += foreground(token.Text, colors.Number)
+		case syntax.Comment:
+			output += foreground(token.Text, colors.Comment)
+		default:
+			output += token.Text
+		}
+	}
+	return output
+}
+
 func QuoteBlock(text string) string {
 	prefixed := ansi.Indent(text, "▌", true)
 	return Color(prefixed)
 }
 
-func LinkBlock(text string, number int) string {
-	return "‣ " + ansi.Indent(Link(text, number), "  ", false)
+func LinkBlock(text string, number int, url string) string {
+	return "‣ " + ansi.Indent(Link(text, number, url), "  ", false)
+}
+
+/*
+Draws a border around `text`, which must already be
+wrapped to `width` minus 4
+*/
+func Frame(text string, width int) string {
+	if width < 5 {
+		return text
+	}
+	padded := ansi.Pad(text, width-4)
+	output := Color("╭" + strings.Repeat("─", width-2) + "╮")
+	for _, line := range strings.Split(padded, "\n") {
+		output += "\n" + Color("│ ") + line + Color(" │")
+	}
+	output += "\n" + Color("╰"+strings.Repeat("─", width-2)+"╯")
+	return output
 }
 
 func Header(text string, level uint) string {
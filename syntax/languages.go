@@ -0,0 +1,179 @@
+package syntax
+
+/* The names used in class="language-…" attributes and after gemtext's opening backticks */
+var aliases = map[string]string{
+	"go": "go", "golang": "go",
+	"python": "python", "py": "python", "python3": "python",
+	"javascript": "javascript", "js": "javascript", "jsx": "javascript", "mjs": "javascript",
+	"typescript": "typescript", "ts": "typescript", "tsx": "typescript",
+	"rust": "rust", "rs": "rust",
+	"c": "c", "h": "c",
+	"cpp": "cpp", "c++": "cpp", "cc": "cpp", "cxx": "cpp", "hpp": "cpp",
+	"kotlin": "kotlin", "kt": "kotlin",
+	"ruby": "ruby", "rb": "ruby",
+	"shell": "shell", "sh": "shell", "bash": "shell", "zsh": "shell", "console": "shell",
+	"haskell": "haskell", "hs": "haskell",
+	"java": "java", "json": "json", "css": "css", "sql": "sql", "lua": "lua", "toml": "toml",
+}
+
+var cKeywords = "auto break case const continue default do else enum extern for goto if inline register " +
+	"restrict return sizeof static struct switch typedef union volatile while"
+var cTypes = "void char short int long float double signed unsigned bool size_t ssize_t " +
+	"int8_t int16_t int32_t int64_t uint8_t uint16_t uint32_t uint64_t FILE NULL true false"
+
+var javascriptKeywords = "async await break case catch class const continue debugger default delete do " +
+	"else export extends finally for from function if import in instanceof let new of return static " +
+	"super switch this throw try typeof var void while with yield"
+var javascriptTypes = "true false null undefined NaN Infinity Array Object String Number Boolean " +
+	"Promise Map Set Symbol Error JSON Math console"
+
+var languages = map[string]*language{
+	"go": {
+		keywords: set("break case chan const continue default defer else fallthrough for func go goto " +
+			"if import interface map package range return select struct switch type var"),
+		types: set("bool byte complex64 complex128 error float32 float64 int int8 int16 int32 int64 " +
+			"rune string uint uint8 uint16 uint32 uint64 uintptr any comparable true false nil iota " +
+			"append cap close copy delete len make new panic print println recover"),
+		lineComments:     []string{"//"},
+		blockComments:    [][2]string{{"/*", "*/"}},
+		multilineStrings: []string{"`"},
+		strings:          []string{`"`, "'"},
+	},
+	"python": {
+		keywords: set("and as assert async await break class continue def del elif else except finally " +
+			"for from global if import in is lambda nonlocal not or pass raise return try while with yield match case"),
+		types: set("True False None self int float str bool bytes list dict set tuple object type " +
+			"len print range open isinstance super Exception"),
+		lineComments:     []string{"#"},
+		multilineStrings: []string{`"""`, "'''"},
+		strings:          []string{`"`, "'"},
+	},
+	"javascript": {
+		keywords:         set(javascriptKeywords),
+		types:            set(javascriptTypes),
+		lineComments:     []string{"//"},
+		blockComments:    [][2]string{{"/*", "*/"}},
+		multilineStrings: []string{"`"},
+		strings:          []string{`"`, "'"},
+	},
+	"typescript": {
+		keywords: set(javascriptKeywords + " abstract as declare enum implements interface keyof namespace " +
+			"private protected public readonly type"),
+		types:            set(javascriptTypes + " any unknown never number string boolean bigint object void"),
+		lineComments:     []string{"//"},
+		blockComments:    [][2]string{{"/*", "*/"}},
+		multilineStrings: []string{"`"},
+		strings:          []string{`"`, "'"},
+	},
+	"rust": {
+		keywords: set("as async await break const continue crate dyn else enum extern fn for if impl in " +
+			"let loop match mod move mut pub ref return self Self static struct super trait type unsafe use where while"),
+		types: set("bool char str String i8 i16 i32 i64 i128 isize u8 u16 u32 u64 u128 usize f32 f64 " +
+			"Vec Option Some None Result Ok Err Box true false"),
+		lineComments:  []string{"//"},
+		blockComments: [][2]string{{"/*", "*/"}},
+		/* Single quotes also begin lifetimes, so only double quoted strings are recognized */
+		multilineStrings: []string{`"`},
+	},
+	"c": {
+		keywords:       set(cKeywords + " #include #define #ifdef #ifndef #endif #if #else #pragma"),
+		types:          set(cTypes),
+		lineComments:   []string{"//"},
+		blockComments:  [][2]string{{"/*", "*/"}},
+		strings:        []string{`"`, "'"},
+		wordCharacters: "#",
+	},
+	"cpp": {
+		keywords: set(cKeywords + " #include #define #ifdef #ifndef #endif #if #else #pragma " +
+			"catch class constexpr delete explicit friend mutable namespace new noexcept operator override " +
+			"private protected public template this throw try typename using virtual"),
+		types:          set(cTypes + " nullptr std string vector map auto"),
+		lineComments:   []string{"//"},
+		blockComments:  [][2]string{{"/*", "*/"}},
+		strings:        []string{`"`, "'"},
+		wordCharacters: "#",
+	},
+	"java": {
+		keywords: set("abstract assert break case catch class continue default do else enum extends final " +
+			"finally for if implements import instanceof interface native new package private protected " +
+			"public return static super switch synchronized this throw throws try var void volatile while record"),
+		types: set("boolean byte char double float int long short String Object Integer List Map " +
+			"true false null"),
+		lineComments:     []string{"//"},
+		blockComments:    [][2]string{{"/*", "*/"}},
+		multilineStrings: []string{`"""`},
+		strings:          []string{`"`, "'"},
+	},
+	"kotlin": {
+		keywords: set("as break class continue do else false for fun if in interface is null object " +
+			"package return super this throw true try typealias val var when while by companion data " +
+			"import override private public internal sealed suspend"),
+		types: set("Any Unit Nothing Int Long Short Byte Double Float Boolean Char String List Map " +
+			"Set Array"),
+		lineComments:     []string{"//"},
+		blockComments:    [][2]string{{"/*", "*/"}},
+		multilineStrings: []string{`"""`},
+		strings:          []string{`"`, "'"},
+	},
+	"ruby": {
+		keywords: set("alias and begin break case class def defined? do else elsif end ensure for if in " +
+			"module next not or redo rescue retry return self super then unless until when while yield " +
+			"require attr_reader attr_accessor puts"),
+		types:        set("true false nil Integer String Array Hash Symbol"),
+		lineComments: []string{"#"},
+		strings:      []string{`"`, "'"},
+	},
+	"shell": {
+		keywords: set("if then else elif fi case esac for while until do done in function return " +
+			"exit export local set unset echo cd source alias"),
+		types:            set("true false"),
+		lineComments:     []string{"#"},
+		multilineStrings: []string{`"`, "'"},
+		wordCharacters:   "-",
+	},
+	"json": {
+		types:   set("true false null"),
+		strings: []string{`"`},
+	},
+	"css": {
+		keywords: set("@media @import @font-face @keyframes @supports !important"),
+		types: set("px em rem vh vw auto none inherit initial block inline flex grid absolute " +
+			"relative fixed solid"),
+		blockComments:  [][2]string{{"/*", "*/"}},
+		strings:        []string{`"`, "'"},
+		wordCharacters: "-@!",
+	},
+	"sql": {
+		keywords: set("select from where insert into values update set delete create table drop alter " +
+			"and or not null is in join left right inner outer on as group by order having limit " +
+			"offset distinct union primary key foreign references index view with case when then else end"),
+		types:           set("int integer bigint text varchar char boolean date timestamp real float true false"),
+		lineComments:    []string{"--"},
+		blockComments:   [][2]string{{"/*", "*/"}},
+		strings:         []string{"'", `"`},
+		caseInsensitive: true,
+	},
+	"lua": {
+		keywords: set("and break do else elseif end for function goto if in local not or repeat return " +
+			"then until while"),
+		types:         set("true false nil self print pairs ipairs require table string math"),
+		blockComments: [][2]string{{"--[[", "]]"}},
+		lineComments:  []string{"--"},
+		strings:       []string{`"`, "'"},
+	},
+	"haskell": {
+		keywords: set("case class data default deriving do else if import in infix infixl infixr instance " +
+			"let module newtype of then type where"),
+		types:         set("Int Integer Float Double Bool Char String Maybe Just Nothing Either Left Right IO True False"),
+		lineComments:  []string{"--"},
+		blockComments: [][2]string{{"{-", "-}"}},
+		strings:       []string{`"`},
+	},
+	"toml": {
+		types:            set("true false"),
+		lineComments:     []string{"#"},
+		multilineStrings: []string{`"""`, "'''"},
+		strings:          []string{`"`, "'"},
+		wordCharacters:   "-",
+	},
+}
@@ -0,0 +1,182 @@
+package syntax
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+/*
+	A small lexer shared by every supported language. Each language
+	is described by its keywords and the way it writes comments and
+	strings, which is enough to colour code without parsing it.
+*/
+
+type Kind int
+
+const (
+	Plain Kind = iota
+	Keyword
+	Type
+	String
+	Number
+	Comment
+)
+
+type Token struct {
+	Kind Kind
+	Text string
+}
+
+/* Tokenizes `code`, returning false if the language is unknown */
+func Tokenize(code string, language string) ([]Token, bool) {
+	definition, ok := languages[aliases[strings.ToLower(language)]]
+	if !ok {
+		return nil, false
+	}
+
+	tokens := []Token{}
+	add := func(kind Kind, text string) {
+		if len(tokens) != 0 && tokens[len(tokens)-1].Kind == kind {
+			tokens[len(tokens)-1].Text += text
+			return
+		}
+		tokens = append(tokens, Token{kind, text})
+	}
+
+	rest := code
+	for rest != "" {
+		if length := definition.comment(rest); length != 0 {
+			add(Comment, rest[:length])
+			rest = rest[length:]
+			continue
+		}
+		if length := definition.literal(rest); length != 0 {
+			add(String, rest[:length])
+			rest = rest[length:]
+			continue
+		}
+
+		character, size := utf8.DecodeRuneInString(rest)
+		if unicode.IsDigit(character) {
+			length := strings.IndexFunc(rest, func(r rune) bool {
+				return !isWordCharacter(r) && r != '.'
+			})
+			if length == -1 {
+				length = len(rest)
+			}
+			add(Number, rest[:length])
+			rest = rest[length:]
+			continue
+		}
+		if isWordCharacter(character) || strings.ContainsRune(definition.wordCharacters, character) {
+			length := strings.IndexFunc(rest, func(r rune) bool {
+				return !isWordCharacter(r) && !strings.ContainsRune(definition.wordCharacters, r)
+			})
+			if length == -1 {
+				length = len(rest)
+			}
+			word := rest[:length]
+			add(definition.classify(word), word)
+			rest = rest[length:]
+			continue
+		}
+
+		add(Plain, rest[:size])
+		rest = rest[size:]
+	}
+	return tokens, true
+}
+
+type language struct {
+	keywords map[string]bool
+	types    map[string]bool
+
+	lineComments  []string
+	blockComments [][2]string
+
+	/* Delimiters of strings that may span lines, then of those that may not */
+	multilineStrings []string
+	strings          []string
+
+	/* Characters besides letters, digits and underscores that may continue a word, e.g. the - in CSS */
+	wordCharacters string
+
+	caseInsensitive bool
+}
+
+func (l *language) comment(text string) int {
+	for _, pair := range l.blockComments {
+		if strings.HasPrefix(text, pair[0]) {
+			end := strings.Index(text[len(pair[0]):], pair[1])
+			if end == -1 {
+				return len(text)
+			}
+			return len(pair[0]) + end + len(pair[1])
+		}
+	}
+	for _, prefix := range l.lineComments {
+		if strings.HasPrefix(text, prefix) {
+			end := strings.IndexByte(text, '\n')
+			if end == -1 {
+				return len(text)
+			}
+			return end
+		}
+	}
+	return 0
+}
+
+func (l *language) literal(text string) int {
+	for _, delimiter := range l.multilineStrings {
+		if strings.HasPrefix(text, delimiter) {
+			return closing(text, delimiter, false)
+		}
+	}
+	for _, delimiter := range l.strings {
+		if strings.HasPrefix(text, delimiter) {
+			return closing(text, delimiter, true)
+		}
+	}
+	return 0
+}
+
+/* The length of the string that opens `text`, including its delimiters */
+func closing(text string, delimiter string, singleLine bool) int {
+	for i := len(delimiter); i < len(text); i++ {
+		switch {
+		case text[i] == '\\':
+			i++
+		case singleLine && text[i] == '\n':
+			return i
+		case strings.HasPrefix(text[i:], delimiter):
+			return i + len(delimiter)
+		}
+	}
+	return len(text)
+}
+
+func (l *language) classify(word string) Kind {
+	if l.caseInsensitive {
+		word = strings.ToLower(word)
+	}
+	if l.keywords[word] {
+		return Keyword
+	}
+	if l.types[word] {
+		return Type
+	}
+	return Plain
+}
+
+func isWordCharacter(character rune) bool {
+	return character == '_' || unicode.IsLetter(character) || unicode.IsDigit(character)
+}
+
+func set(words string) map[string]bool {
+	result := map[string]bool{}
+	for _, word := range strings.Fields(words) {
+		result[word] = true
+	}
+	return result
+}
@@ -0,0 +1,66 @@
+package syntax
+
+import (
+	"testing"
+)
+
+func TestGo(t *testing.T) {
+	tokens, ok := Tokenize("func f() int { return 42 } // answer", "go")
+	if !ok {
+		t.Fatal("expected go to be known")
+	}
+	expected := []Token{
+		{Keyword, "func"},
+		{Plain, " f() "},
+		{Type, "int"},
+		{Plain, " { "},
+		{Keyword, "return"},
+		{Plain, " "},
+		{Number, "42"},
+		{Plain, " } "},
+		{Comment, "// answer"},
+	}
+	if len(tokens) != len(expected) {
+		t.Fatalf("expected %v not %v", expected, tokens)
+	}
+	for i := range expected {
+		if tokens[i] != expected[i] {
+			t.Fatalf("expected %v not %v", expected, tokens)
+		}
+	}
+}
+
+func TestStrings(t *testing.T) {
+	tokens, ok := Tokenize(`print("a \" # b") # c`, "py")
+	if !ok {
+		t.Fatal("expected py to be known")
+	}
+	expected := []Token{
+		{Type, "print"},
+		{Plain, "("},
+		{String, `"a \" # b"`},
+		{Plain, ") "},
+		{Comment, "# c"},
+	}
+	if len(tokens) != len(expected) {
+		t.Fatalf("expected %v not %v", expected, tokens)
+	}
+	for i := range expected {
+		if tokens[i] != expected[i] {
+			t.Fatalf("expected %v not %v", expected, tokens)
+		}
+	}
+}
+
+func TestCaseInsensitive(t *testing.T) {
+	tokens, _ := Tokenize("SELECT x", "sql")
+	if tokens[0] != (Token{Keyword, "SELECT"}) {
+		t.Fatalf("expected SELECT to be a keyword, not %v", tokens[0])
+	}
+}
+
+func TestUnknownLanguage(t *testing.T) {
+	if _, ok := Tokenize("anything", "brainfuck"); ok {
+		t.Fatal("expected the language to be unknown")
+	}
+}
@@ -0,0 +1,168 @@
+package ui
+
+import (
+	"errors"
+	"servitor/config"
+	"servitor/pub"
+	"sort"
+	"time"
+)
+
+/*
+Collections can only be read page by page, from one end to the other,
+so they can't be bisected; seeking loads pages until it passes the
+date, this many items at a time
+*/
+const seekStep = 40
+
+/*
+The most times seeking will harvest before stopping to say the date
+wasn't reached, after which seeking again continues where it stopped
+*/
+const maxSeekHarvests = 25
+
+/* Reads the children of the current page from their other end, e.g. to jump to an actor's oldest posts */
+func (s *State) reverseChildren() {
+	page := s.h.Current()
+	if page.origin == nil || page.loadingDown || page.thread != nil {
+		return
+	}
+	origin := page.origin
+	s.mode = loading
+	s.buffer = ""
+	s.output(s.view())
+	go func() {
+		reversed, err := origin.Reverse()
+		var children []pub.Tangible
+		var nextCollection pub.Container
+		var newBasepoint uint
+		if err == nil {
+			children, nextCollection, newBasepoint = reversed.Harvest(uint(config.Parsed.Network.Context+1), 0)
+			if len(children) == 0 {
+				err = errors.New("there is nothing at the other end")
+			}
+		}
+
+		s.m.Lock()
+		defer s.m.Unlock()
+		if err != nil {
+			s.mode = problem
+			s.buffer = "Failed to read from the other end: " + err.Error()
+			s.output(s.view())
+			s.mode = normal
+			s.buffer = ""
+			return
+		}
+		page.origin = reversed
+		page.oldestFirst = !page.oldestFirst
+		page.children = nextCollection
+		page.basepoint = newBasepoint
+		page.seen = nil
+		page.feed.ReplaceChildren(page.deduplicate(children))
+		page.feed.MoveTo(1)
+		s.mode = normal
+		s.buffer = ""
+		s.loadSurroundings()
+		s.output(s.view())
+	}()
+}
+
+/*
+Accepts either a day, in which case any time on that day
+matches, or an exact time
+*/
+func parseDate(input string) (time.Time, time.Time, error) {
+	if day, err := time.ParseInLocation("2006-01-02", input, time.Local); err == nil {
+		return day, day.AddDate(0, 0, 1), nil
+	}
+	if moment, err := time.Parse(time.RFC3339, input); err == nil {
+		return moment, moment.Add(time.Nanosecond), nil
+	}
+	return time.Time{}, time.Time{}, errors.New("dates must look like 2006-01-02 or 2006-01-02T15:04:05Z")
+}
+
+/* Moves to the first child of the current page from the given date, loading children until it is found */
+func (s *State) seek(input string) error {
+	start, end, err := parseDate(input)
+	if err != nil {
+		return err
+	}
+	page := s.h.Current()
+	if page.thread != nil {
+		return errors.New("can't seek within a threaded view")
+	}
+	if page.loadingDown {
+		return errors.New("can't seek while items are loading")
+	}
+
+	/* In a newest-first list, the first item from the date is the first
+	   one before its end, otherwise it's the first one after its start */
+	reached := func(timestamp time.Time) bool {
+		if page.oldestFirst {
+			return !timestamp.Before(start)
+		}
+		return timestamp.Before(end)
+	}
+
+	page.loadingDown = true
+	s.mode = loading
+	s.buffer = ""
+	s.output(s.view())
+	go func() {
+		s.m.Lock()
+		defer s.m.Unlock()
+		passed := func() bool {
+			timestamp, ok := nearestTimestamp(page, page.feed.LastIndex(), -1)
+			return ok && reached(timestamp)
+		}
+		for i := 0; i < maxSeekHarvests && page.children != nil && !passed(); i++ {
+			children, basepoint := page.children, page.basepoint
+			s.m.Unlock()
+			harvested, nextCollection, newBasepoint := children.Harvest(seekStep, basepoint)
+			s.m.Lock()
+			page.feed.Append(page.deduplicate(harvested))
+			page.children = nextCollection
+			page.basepoint = newBasepoint
+		}
+		page.loadingDown = false
+
+		/* The loaded children are ordered by time, so the first one from the date can be found by bisection */
+		count := page.feed.LastIndex()
+		found := sort.Search(count, func(i int) bool {
+			timestamp, ok := nearestTimestamp(page, i+1, 1)
+			return !ok || reached(timestamp)
+		})
+		if !passed() && page.children != nil {
+			/* Rather than landing on whichever item was loaded last */
+			s.mode = problem
+			s.buffer = "Failed to seek: " + input + " wasn't reached within the items loaded; seek again to load more"
+		} else if found == count {
+			s.mode = problem
+			s.buffer = "Failed to seek: the list ends before reaching " + input
+		} else {
+			page.feed.MoveTo(found + 1)
+			s.mode = normal
+			s.buffer = ""
+		}
+		s.loadSurroundings()
+		s.output(s.view())
+		s.mode = normal
+		s.buffer = ""
+	}()
+	return nil
+}
+
+/*
+Returns the timestamp of the child at the index, or failing that the
+nearest child in the given direction that has one, since failures
+and some other items lack timestamps
+*/
+func nearestTimestamp(page *Page, index int, direction int) (time.Time, bool) {
+	for ; index >= 1 && index <= page.feed.LastIndex(); index += direction {
+		timestamp := page.feed.Get(index - page.feed.Index()).Timestamp()
+		if !timestamp.IsZero() {
+			return timestamp, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,400 @@
+package ui
+
+import (
+	"servitor/ansi"
+	"servitor/config"
+	"servitor/pub"
+	"servitor/style"
+	"strings"
+)
+
+/*
+A thread holds the comments of a post as a tree so they can be
+displayed with their nesting, rather than as a flat list. Comments
+are loaded depth-first, and only the comments before the first
+node with unloaded children are visible, so the order of the
+visible comments never changes as more are loaded.
+
+Comments come from each post's replies, and also from the root's
+context collection, if it has one, which often includes replies
+that never federated to the servers of the posts they reply to.
+The context is only read once every reply is visible, a page at a
+time, and its posts are added beneath the posts they reply to.
+*/
+type thread struct {
+	root    *node
+	visible []*node
+	/* The first visible node with unloaded children, nil once everything has loaded */
+	gap *node
+
+	/* The unread remainder of the context, nil once it has been read */
+	context          pub.Container
+	contextBasepoint uint
+	contextRead      int
+	hasContext       bool
+	/* The posts of the context whose parents haven't been added yet, by the identifier of their parent */
+	byParent map[string][]pub.Tangible
+	/* Every post in the thread by its identifier, to avoid duplicates */
+	seen map[string]*node
+}
+
+type node struct {
+	item      pub.Tangible
+	depth     int
+	parent    *node
+	children  []*node
+	container pub.Container
+	basepoint uint
+	/* Whether the replies from the context have been added */
+	merged    bool
+	collapsed bool
+	/* Whether the post was found in the context but not among the replies */
+	viaContext bool
+}
+
+/* The most posts that will be read from a context collection */
+const maxContextSize = 500
+
+func newThread(root pub.Tangible) *thread {
+	t := &thread{
+		root: &node{
+			item:      root,
+			container: root.Children(),
+		},
+		byParent: map[string][]pub.Tangible{},
+		seen:     map[string]*node{},
+	}
+	if post, ok := root.(*pub.Post); ok {
+		t.context = post.Context()
+	}
+	if id, ok := identifier(root); ok {
+		t.seen[id] = t.root
+	}
+	t.hasContext = t.context != nil
+	t.root.merged = !t.hasContext
+	t.update()
+	return t
+}
+
+func identifier(item pub.Tangible) (string, bool) {
+	if post, ok := item.(*pub.Post); ok && post.Identifier() != nil {
+		return post.Identifier().String(), true
+	}
+	return "", false
+}
+
+func (t *thread) update() {
+	t.visible = []*node{}
+	t.gap = nil
+
+	var visit func(n *node) bool
+	visit = func(n *node) bool {
+		if n.collapsed {
+			return true
+		}
+		for _, child := range n.children {
+			t.visible = append(t.visible, child)
+			if !visit(child) {
+				return false
+			}
+		}
+		if n.container != nil || !n.merged {
+			t.gap = n
+			return false
+		}
+		return true
+	}
+	visit(t.root)
+}
+
+/* Whether more comments may be loaded, from replies or the context */
+func (t *thread) pending() bool {
+	return t.gap != nil || t.context != nil
+}
+
+/*
+Adds the posts of a page of the context beneath the posts they reply to,
+keeping those whose parents haven't been merged for when they are
+*/
+func (t *thread) distribute(items []pub.Tangible) int {
+	added := 0
+	for _, item := range items {
+		post, ok := item.(*pub.Post)
+		if !ok || post.ParentIdentifier() == nil {
+			continue
+		}
+		parent := post.ParentIdentifier().String()
+		if n := t.seen[parent]; n != nil && n.merged {
+			added += t.add(n, []pub.Tangible{post}, true)
+		} else {
+			t.byParent[parent] = append(t.byParent[parent], post)
+		}
+	}
+	return added
+}
+
+/* Adds the replies from the context that weren't found among the replies themselves */
+func (t *thread) merge(n *node) int {
+	n.merged = true
+	id, ok := identifier(n.item)
+	if !ok {
+		return 0
+	}
+	items := t.byParent[id]
+	delete(t.byParent, id)
+	return t.add(n, items, true)
+}
+
+func (t *thread) items() []pub.Tangible {
+	output := make([]pub.Tangible, len(t.visible))
+	for i, n := range t.visible {
+		output[i] = n.item
+	}
+	return output
+}
+
+/* Returns the node at the given feed index, where the root is at 0 */
+func (t *thread) at(index int) *node {
+	if index == 0 {
+		return t.root
+	}
+	if index < 1 || index > len(t.visible) {
+		return nil
+	}
+	return t.visible[index-1]
+}
+
+/* Returns the feed index of the node, or false if it isn't visible */
+func (t *thread) indexOf(target *node) (int, bool) {
+	if target == t.root {
+		return 0, true
+	}
+	for i, n := range t.visible {
+		if n == target {
+			return i + 1, true
+		}
+	}
+	return 0, false
+}
+
+func (t *thread) add(n *node, items []pub.Tangible, viaContext bool) int {
+	added := 0
+	for _, item := range items {
+		child := &node{
+			item:       item,
+			depth:      n.depth + 1,
+			parent:     n,
+			container:  item.Children(),
+			merged:     !t.hasContext,
+			viaContext: viaContext,
+		}
+		if id, ok := identifier(item); ok {
+			if t.seen[id] != nil {
+				continue
+			}
+			t.seen[id] = child
+		}
+		n.children = append(n.children, child)
+		added += 1
+	}
+	return added
+}
+
+/* Replaces an item wherever it is in the thread, returning false if it isn't present */
+func (t *thread) swap(old, new pub.Tangible) bool {
+	var visit func(n *node) bool
+	visit = func(n *node) bool {
+		if n.item == old {
+			n.item = new
+			n.container = new.Children()
+			return true
+		}
+		for _, child := range n.children {
+			if visit(child) {
+				return true
+			}
+		}
+		return false
+	}
+	if !visit(t.root) {
+		return false
+	}
+	t.update()
+	return true
+}
+
+func (n *node) hasReplies() bool {
+	return len(n.children) != 0 || n.container != nil || !n.merged
+}
+
+/* Returns the sibling `offset` positions away, or nil if there is none */
+func (n *node) sibling(offset int) *node {
+	if n.parent == nil {
+		return nil
+	}
+	siblings := n.parent.children
+	for i, sibling := range siblings {
+		if sibling == n {
+			if i+offset < 0 || i+offset >= len(siblings) {
+				return nil
+			}
+			return siblings[i+offset]
+		}
+	}
+	return nil
+}
+
+/*
+Draws a guide for each level of nesting beneath the direct replies,
+up to a limit so deep threads don't crowd out the text
+*/
+func (n *node) render(width int) string {
+	levels := n.depth - 1
+	if maxLevels := width / 8; levels > maxLevels {
+		levels = maxLevels
+	}
+	guides := style.Color(strings.Repeat("│ ", levels))
+	width -= 2*levels + 4
+
+	marker := "→ "
+	if n.collapsed {
+		marker = "+ "
+	}
+
+	output := n.item.Preview(width)
+	if n.viaContext {
+		output += "\n" + style.Color("found only via the conversation's context")
+	}
+	if n.collapsed {
+		output += "\n" + style.Color("replies collapsed")
+	}
+	return guides + marker + ansi.Indent(output, guides+"  ", false)
+}
+
+/* Switches the replies of the post at the center between the flat and threaded views */
+func (s *State) toggleThread() {
+	page := s.h.Current()
+	if page.loadingDown || !page.feed.Contains(-page.feed.Index()) {
+		return
+	}
+	center := page.feed.Get(-page.feed.Index())
+	if page.thread == nil {
+		page.thread = newThread(center)
+		if !page.thread.pending() {
+			page.thread = nil
+			return
+		}
+		page.children = nil
+	} else {
+		page.thread = nil
+		page.children = center.Children()
+		page.origin = center.Children()
+		page.oldestFirst = false
+	}
+	page.basepoint = 0
+	page.seen = nil
+	page.feed.ReplaceChildren([]pub.Tangible{})
+	s.loadSurroundings()
+}
+
+/*
+Loads replies depth-first until enough are visible or the
+thread is exhausted, expects loadingDown to already be set
+*/
+func (s *State) loadThread(page *Page) {
+	context := config.Parsed.Network.Context
+	s.m.Lock()
+	defer s.m.Unlock()
+	thread := page.thread
+	for !page.feed.Contains(context) && thread.pending() {
+		gap := thread.gap
+		highlighted := thread.at(page.feed.Index())
+		var added int
+		if gap != nil && gap.container != nil {
+			container, basepoint := gap.container, gap.basepoint
+			s.m.Unlock()
+			children, nextCollection, newBasepoint := container.Harvest(uint(context), basepoint)
+			s.m.Lock()
+			added = thread.add(gap, children, false)
+			gap.container = nextCollection
+			gap.basepoint = newBasepoint
+		} else if gap != nil {
+			added = thread.merge(gap)
+		} else {
+			/* Every reply is visible, so the context is read, a page at a time */
+			container, basepoint := thread.context, thread.contextBasepoint
+			s.m.Unlock()
+			items, nextCollection, newBasepoint := container.Harvest(uint(context), basepoint)
+			s.m.Lock()
+			thread.context = nextCollection
+			thread.contextBasepoint = newBasepoint
+			thread.contextRead += len(items)
+			if len(items) == 0 || thread.contextRead >= maxContextSize {
+				thread.context = nil
+			}
+			added = thread.distribute(items)
+		}
+		thread.update()
+		page.feed.ReplaceChildren(thread.items())
+		/* Posts from the context can be added above the highlighted one, which stays highlighted */
+		if index, ok := thread.indexOf(highlighted); ok && highlighted != nil {
+			page.feed.MoveTo(index)
+		}
+		if added != 0 {
+			s.output(s.view())
+		}
+	}
+	page.loadingDown = false
+	s.output(s.view())
+}
+
+/* Collapses or expands the replies beneath the highlighted comment */
+func (s *State) toggleCollapsed() {
+	page := s.h.Current()
+	if page.thread == nil {
+		return
+	}
+	n := page.thread.at(page.feed.Index())
+	if n == nil || n == page.thread.root || !n.hasReplies() {
+		return
+	}
+	n.collapsed = !n.collapsed
+	page.thread.update()
+	page.feed.ReplaceChildren(page.thread.items())
+	s.loadSurroundings()
+}
+
+/* Moves to the comment the highlighted one replies to */
+func (s *State) moveToParent() {
+	page := s.h.Current()
+	if page.thread == nil {
+		return
+	}
+	n := page.thread.at(page.feed.Index())
+	if n == nil || n.parent == nil {
+		return
+	}
+	if index, ok := page.thread.indexOf(n.parent); ok {
+		page.feed.MoveTo(index)
+	}
+}
+
+/* Moves to the next or previous reply to the same comment */
+func (s *State) moveToSibling(offset int) {
+	page := s.h.Current()
+	if page.thread == nil {
+		return
+	}
+	n := page.thread.at(page.feed.Index())
+	if n == nil {
+		return
+	}
+	sibling := n.sibling(offset)
+	if sibling == nil {
+		return
+	}
+	if index, ok := page.thread.indexOf(sibling); ok {
+		page.feed.MoveTo(index)
+		s.loadSurroundings()
+	}
+}
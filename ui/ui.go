@@ -5,6 +5,7 @@ import (
 	"servitor/ansi"
 	"servitor/config"
 	"servitor/feed"
+	"servitor/graphics"
 	"servitor/history"
 	"servitor/mime"
 	"servitor/pub"
@@ -47,6 +48,16 @@ type Page struct {
 	children    pub.Container
 	basepoint   uint
 	loadingDown bool
+
+	/* The container the children were first harvested from */
+	origin      pub.Container
+	oldestFirst bool
+
+	/* Set while the replies are shown as a tree, in which case children is unused */
+	thread *thread
+
+	/* The identifiers of the posts among the children, to hide repeated group announces */
+	seen map[string]bool
 }
 
 type State struct {
@@ -80,6 +91,8 @@ func (s *State) view() string {
 		var serialized string
 		if s.h.Current().feed.IsParent(i) {
 			serialized = s.h.Current().feed.Get(i).Preview(s.width - 4)
+		} else if thread := s.h.Current().thread; thread != nil && s.h.Current().feed.IsChild(i) {
+			serialized = thread.at(s.h.Current().feed.Index() + i).render(s.width - 4)
 		} else if s.h.Current().feed.IsChild(i) {
 			serialized = "→ " + ansi.Indent(s.h.Current().feed.Get(i).Preview(s.width-8), "  ", false)
 		} else {
@@ -125,7 +138,7 @@ func (s *State) view() string {
 	case normal:
 		break
 	case selection:
-		footer = "Selecting " + s.buffer + " (press . to open internally, enter to open externally)"
+		footer = "Selecting " + s.buffer + " (press enter to open, . to open internally)"
 	case command:
 		footer = ":" + s.buffer
 	case opening:
@@ -229,6 +242,11 @@ func (s *State) Update(input byte) {
 				s.openInternally(link)
 				return
 			}
+			/* Links to ActivityPub objects, like mentions, are opened within servitor */
+			if input == enterKey && mediaType.IsActivityPub() {
+				s.openInternally(link)
+				return
+			}
 			if input == enterKey {
 				s.openExternally(link, mediaType)
 				return
@@ -247,6 +265,59 @@ func (s *State) Update(input byte) {
 	case 'j': // down
 		s.h.Current().feed.MoveDown()
 		s.loadSurroundings()
+	case 't': // toggle the threaded view of replies
+		s.toggleThread()
+	case 'z': // collapse or expand the replies to a comment
+		s.toggleCollapsed()
+	case 'U': // move to the comment being replied to
+		s.moveToParent()
+	case 'J': // move to the next reply to the same comment
+		s.moveToSibling(1)
+	case 'K': // move to the previous reply to the same comment
+		s.moveToSibling(-1)
+	case 'i': // inspect the object behind the highlighted item
+		inspector, err := pub.Inspect(s.h.Current().feed.Current())
+		if err != nil {
+			s.mode = problem
+			s.buffer = "Failed to inspect the highlighted item: " + err.Error()
+			s.output(s.view())
+			s.mode = normal
+			s.buffer = ""
+			return
+		}
+		s.switchTo(inspector)
+	case 'I': // draw or hide the images of the highlighted item
+		unwrapped := s.h.Current().feed.Current()
+		if activity, ok := unwrapped.(*pub.Activity); ok {
+			unwrapped = activity.Target()
+		}
+		if item, ok := unwrapped.(interface{ ToggleImages() }); ok {
+			item.ToggleImages()
+		}
+	case 'x': // expand or collapse the details in the highlighted post
+		if err := s.toggleDetails(); err != nil {
+			s.mode = problem
+			s.buffer = err.Error()
+			s.output(s.view())
+			s.mode = normal
+			s.buffer = ""
+			return
+		}
+	case 'S': // switch between the content and source of the highlighted post
+		if err := s.toggleSource(); err != nil {
+			s.mode = problem
+			s.buffer = err.Error()
+			s.output(s.view())
+			s.mode = normal
+			s.buffer = ""
+			return
+		}
+	case 'R': // retry loading the highlighted item if it failed
+		if s.retry() {
+			return
+		}
+	case 'G': // read the children from the other end, e.g. jump to the oldest post
+		s.reverseChildren()
 	case 'g': // return to OP
 		s.h.Current().feed.MoveToCenter()
 	case 'h': // back in history
@@ -264,6 +335,10 @@ func (s *State) Update(input byte) {
 			creators := post.Creators()
 			s.switchTo(creators)
 		}
+	case 'E': // fetch the highlighted post again and show how it was edited
+		s.viewEdits()
+	case 'd': // view everyone the highlighted post is addressed to
+		s.viewAddressees()
 	case 'r': // get recipient of post
 		unwrapped := s.h.Current().feed.Current()
 		if activity, ok := unwrapped.(*pub.Activity); ok {
@@ -288,6 +363,73 @@ func (s *State) Update(input byte) {
 				s.openExternally(link, mediaType)
 			}
 		}
+	case 'O': // list every stream of a post
+		unwrapped := s.h.Current().feed.Current()
+		if activity, ok := unwrapped.(*pub.Activity); ok {
+			unwrapped = activity.Target()
+		}
+		if post, ok := unwrapped.(*pub.Post); ok {
+			if streams, present := post.Streams(); present {
+				s.switchTo(streams)
+			}
+		}
+	case 'q': // open the quoted post
+		unwrapped := s.h.Current().feed.Current()
+		if activity, ok := unwrapped.(*pub.Activity); ok {
+			unwrapped = activity.Target()
+		}
+		if post, ok := unwrapped.(*pub.Post); ok {
+			if link, present := post.Quote(); present {
+				s.openInternally(link)
+				return
+			}
+		}
+	case 'v': // view the likes of a post
+		unwrapped := s.h.Current().feed.Current()
+		if activity, ok := unwrapped.(*pub.Activity); ok {
+			unwrapped = activity.Target()
+		}
+		if post, ok := unwrapped.(*pub.Post); ok {
+			s.openLazily(post.Likes)
+			return
+		}
+	case 's': // view the shares of a post
+		unwrapped := s.h.Current().feed.Current()
+		if activity, ok := unwrapped.(*pub.Activity); ok {
+			unwrapped = activity.Target()
+		}
+		if post, ok := unwrapped.(*pub.Post); ok {
+			s.openLazily(post.Shares)
+			return
+		}
+	case 'P', 'F', 'W', 'N', 'T': // view one of an actor's collections
+		if actor, ok := s.h.Current().feed.Current().(*pub.Actor); ok {
+			var fetch func() (any, bool)
+			switch input {
+			case 'P':
+				fetch = func() (any, bool) {
+					children := actor.Children()
+					return children, children != nil
+				}
+			case 'F':
+				fetch = actor.Followers
+			case 'W':
+				fetch = actor.Following
+			case 'N':
+				fetch = actor.Pinned
+			case 'T':
+				fetch = actor.FeaturedTags
+			}
+			s.openActorCollection(actor, fetch)
+			return
+		}
+	case 'M': // view the account an actor moved to
+		if actor, ok := s.h.Current().feed.Current().(*pub.Actor); ok {
+			if link, present := actor.MovedTo(); present {
+				s.openInternally(link)
+				return
+			}
+		}
 	case 'p':
 		if actor, ok := s.h.Current().feed.Current().(*pub.Actor); ok {
 			if link, mediaType, present := actor.ProfilePic(); present {
@@ -311,10 +453,12 @@ func (s *State) switchTo(item any) {
 			return
 		}
 		if len(narrowed) == 1 {
+			s.prefetch(narrowed[0])
 			_, frontier := narrowed[0].Parents(0)
 			s.h.Add(&Page{
 				feed:     feed.Create(narrowed[0]),
 				children: narrowed[0].Children(),
+				origin:   narrowed[0].Children(),
 				frontier: frontier,
 			})
 		} else {
@@ -323,10 +467,12 @@ func (s *State) switchTo(item any) {
 			})
 		}
 	case pub.Tangible:
+		s.prefetch(narrowed)
 		_, frontier := narrowed.Parents(0)
 		s.h.Add(&Page{
 			feed:     feed.Create(narrowed),
 			children: narrowed.Children(),
+			origin:   narrowed.Children(),
 			frontier: frontier,
 		})
 	case pub.Container:
@@ -336,11 +482,13 @@ func (s *State) switchTo(item any) {
 			s.output(s.view())
 		}
 		children, nextCollection, newBasepoint := narrowed.Harvest(uint(config.Parsed.Network.Context + 1), 0)
-		s.h.Add(&Page{
+		page := &Page{
 			basepoint: newBasepoint,
 			children:  nextCollection,
-			feed:      feed.CreateAndAppend(children),
-		})
+			origin:    narrowed,
+		}
+		page.feed = feed.CreateAndAppend(page.deduplicate(children))
+		s.h.Add(page)
 		s.mode = normal
 		s.buffer = ""
 	default:
@@ -375,13 +523,17 @@ func (s *State) loadSurroundings() {
 			s.m.Unlock()
 		}()
 	}
+	if !page.loadingDown && !page.feed.Contains(context) && page.thread != nil && page.thread.pending() {
+		page.loadingDown = true
+		go s.loadThread(page)
+	}
 	if !page.loadingDown && !page.feed.Contains(context) && page.children != nil {
 		page.loadingDown = true
 		go func() {
 			// TODO: need to do a new renaming, maybe upperFrontier, lowerFrontier
 			children, nextCollection, newBasepoint := page.children.Harvest(uint(context), page.basepoint)
 			s.m.Lock()
-			page.feed.Append(children)
+			page.feed.Append(page.deduplicate(children))
 			page.children = nextCollection
 			page.basepoint = newBasepoint
 			page.loadingDown = false
@@ -391,6 +543,36 @@ func (s *State) loadSurroundings() {
 	}
 }
 
+/*
+Per FEP-1b12, groups announce every activity done within them, so a
+post can be announced once when it's created, again when it's edited,
+and so on; only the first announce of each post is kept
+*/
+func (page *Page) deduplicate(items []pub.Tangible) []pub.Tangible {
+	if page.seen == nil {
+		page.seen = map[string]bool{}
+	}
+	output := make([]pub.Tangible, 0, len(items))
+	for _, item := range items {
+		unwrapped := item
+		activity, isActivity := item.(*pub.Activity)
+		if isActivity {
+			unwrapped = activity.Target()
+		}
+		id, ok := identifier(unwrapped)
+		if !ok {
+			output = append(output, item)
+			continue
+		}
+		if page.seen[id] && isActivity && activity.Relayed() {
+			continue
+		}
+		page.seen[id] = true
+		output = append(output, item)
+	}
+	return output
+}
+
 func (s *State) openUserInput(input string) {
 	s.mode = loading
 	s.buffer = ""
@@ -421,6 +603,223 @@ func (s *State) openInternally(input string) {
 	}()
 }
 
+/* Opens the result of a fetch that may make requests and may have nothing to show */
+func (s *State) openLazily(fetch func() (any, bool)) {
+	s.mode = loading
+	s.buffer = ""
+	s.output(s.view())
+	go func() {
+		result, present := fetch()
+		s.m.Lock()
+		if present {
+			s.switchTo(result)
+		}
+		s.mode = normal
+		s.buffer = ""
+		s.output(s.view())
+		s.m.Unlock()
+	}()
+}
+
+/*
+Shows the actor with the given collection as its children,
+replacing the current page if the actor is the one it is centered on
+*/
+func (s *State) openActorCollection(actor *pub.Actor, fetch func() (any, bool)) {
+	s.mode = loading
+	s.buffer = ""
+	s.output(s.view())
+	go func() {
+		result, present := fetch()
+		s.m.Lock()
+		defer s.m.Unlock()
+		s.mode = normal
+		s.buffer = ""
+		if !present {
+			s.output(s.view())
+			return
+		}
+		page := &Page{feed: feed.Create(actor)}
+		switch narrowed := result.(type) {
+		case pub.Container:
+			page.children = narrowed
+			page.origin = narrowed
+		case pub.Tangible:
+			page.feed.Append([]pub.Tangible{narrowed})
+		}
+		current := s.h.Current().feed
+		if !current.IsParent(0) && !current.IsChild(0) {
+			s.h.Replace(page)
+		} else {
+			s.h.Add(page)
+		}
+		s.loadSurroundings()
+		s.output(s.view())
+	}()
+}
+
+/* Expands or collapses the details in the highlighted post, returning an error if it has none */
+func (s *State) toggleDetails() error {
+	unwrapped := s.h.Current().feed.Current()
+	if activity, ok := unwrapped.(*pub.Activity); ok {
+		unwrapped = activity.Target()
+	}
+	if post, ok := unwrapped.(*pub.Post); ok && post.ToggleDetails() {
+		return nil
+	}
+	return errors.New("There is nothing to expand in the highlighted item")
+}
+
+/* Switches the highlighted post between its content and source, returning an error if it has no source */
+func (s *State) toggleSource() error {
+	unwrapped := s.h.Current().feed.Current()
+	if activity, ok := unwrapped.(*pub.Activity); ok {
+		unwrapped = activity.Target()
+	}
+	if post, ok := unwrapped.(*pub.Post); ok && post.ToggleSource() {
+		return nil
+	}
+	return errors.New("The highlighted item has no source that can be rendered")
+}
+
+/*
+Constructs the highlighted item again if it failed, swapping the result in
+where it was; returns whether it began, in which case a message says so
+*/
+func (s *State) retry() bool {
+	page := s.h.Current()
+	failure, ok := page.feed.Current().(*pub.Failure)
+	if !ok || !failure.Retryable() {
+		return false
+	}
+	s.mode = problem
+	s.buffer = "Retrying\u2026"
+	s.output(s.view())
+	s.mode = normal
+	s.buffer = ""
+	go func() {
+		item := failure.Retry()
+		s.m.Lock()
+		defer s.m.Unlock()
+		if page.thread != nil && page.thread.swap(failure, item) {
+			page.feed.ReplaceChildren(page.thread.items())
+		} else if !page.feed.Swap(failure, item) {
+			return
+		}
+
+		/* A page centered on a failure had nothing to load around it */
+		if page.feed.Contains(-page.feed.Index()) && page.feed.Get(-page.feed.Index()) == item && page.thread == nil {
+			s.prefetch(item)
+			page.children = item.Children()
+			page.origin = item.Children()
+			_, page.frontier = item.Parents(0)
+			if page == s.h.Current() {
+				s.loadSurroundings()
+			}
+		} else if page.feed.FirstIndex() < 0 && page.feed.Get(page.feed.FirstIndex()-page.feed.Index()) == item {
+			/* A failed parent had no parents of its own, so loading upwards stopped there */
+			_, page.frontier = item.Parents(0)
+			if page == s.h.Current() {
+				s.loadSurroundings()
+			}
+		}
+		s.output(s.view())
+	}()
+	return true
+}
+
+/*
+Fetches the highlighted post again to check it for edits, showing
+what changed since the last version that was seen
+*/
+func (s *State) viewEdits() {
+	page := s.h.Current()
+	highlighted := page.feed.Current()
+	unwrapped := highlighted
+	if activity, ok := unwrapped.(*pub.Activity); ok {
+		unwrapped = activity.Target()
+	}
+	post, ok := unwrapped.(*pub.Post)
+	if !ok {
+		return
+	}
+	s.mode = loading
+	s.buffer = ""
+	s.output(s.view())
+	go func() {
+		fetched, revision, err := post.Revise()
+		s.m.Lock()
+		defer s.m.Unlock()
+		/* Either way, the post is now up to date */
+		if fetched != nil && highlighted == unwrapped {
+			if page.thread != nil && page.thread.swap(post, fetched) {
+				page.feed.ReplaceChildren(page.thread.items())
+			} else {
+				page.feed.Swap(post, fetched)
+			}
+		}
+		if err != nil {
+			s.mode = problem
+			s.buffer = "Failed to show edits: " + err.Error()
+			s.output(s.view())
+			s.mode = normal
+			s.buffer = ""
+			return
+		}
+		s.mode = normal
+		s.switchTo(revision)
+		s.output(s.view())
+	}()
+}
+
+/* Fetches the actors a post is addressed to, which may take a while */
+func (s *State) viewAddressees() {
+	unwrapped := s.h.Current().feed.Current()
+	if activity, ok := unwrapped.(*pub.Activity); ok {
+		unwrapped = activity.Target()
+	}
+	post, ok := unwrapped.(*pub.Post)
+	if !ok {
+		return
+	}
+	s.mode = loading
+	s.buffer = ""
+	s.output(s.view())
+	go func() {
+		addressees := post.Addressees()
+		s.m.Lock()
+		defer s.m.Unlock()
+		if len(addressees) == 0 {
+			s.mode = problem
+			s.buffer = "The post isn't addressed to anyone in particular"
+			s.output(s.view())
+			s.mode = normal
+			s.buffer = ""
+			return
+		}
+		s.mode = normal
+		s.switchTo(addressees)
+		s.output(s.view())
+	}()
+}
+
+/* Counts on the pages of actors and posts need requests that aren't worth making for every item */
+func (s *State) prefetch(item pub.Tangible) {
+	if activity, ok := item.(*pub.Activity); ok {
+		item = activity.Target()
+	}
+	prefetchable, ok := item.(interface{ Prefetch() })
+	if !ok {
+		return
+	}
+	go func() {
+		prefetchable.Prefetch()
+		s.m.Lock()
+		s.output(s.view())
+		s.m.Unlock()
+	}()
+}
+
 func (s *State) openFeed(input string) {
 	inputs, present := config.Parsed.Feeds[input]
 	if !present {
@@ -443,21 +842,111 @@ func (s *State) openFeed(input string) {
 	}()
 }
 
+/*
+Rewrites the feed's entries for accounts that have moved so
+they refer to the new accounts, then opens the feed
+*/
+func (s *State) migrateFeed(input string) {
+	entries, present := config.Parsed.Feeds[input]
+	if !present {
+		s.mode = problem
+		s.buffer = "Failed to migrate feed: " + input + " is not a known feed"
+		s.output(s.view())
+		s.mode = normal
+		s.buffer = ""
+		return
+	}
+	entries = append([]string{}, entries...)
+	s.mode = loading
+	s.buffer = ""
+	s.output(s.view())
+	go func() {
+		replacements := make([]string, len(entries))
+		var wg sync.WaitGroup
+		for i, entry := range entries {
+			i, entry := i, entry
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				actor, ok := pub.FetchUserInput(entry).(*pub.Actor)
+				if !ok {
+					return
+				}
+				link, moved := actor.MovedTo()
+				if !moved {
+					return
+				}
+				/* Keep the entry in the form it was written in */
+				address, _ := actor.MovedToAddress()
+				if strings.HasPrefix(entry, "@") {
+					replacements[i] = address
+				} else if strings.HasPrefix(entry, "!") {
+					replacements[i] = "!" + strings.TrimPrefix(address, "@")
+				} else {
+					replacements[i] = link
+				}
+			}()
+		}
+		wg.Wait()
+
+		s.m.Lock()
+		migrated := 0
+		var problems []error
+		for i, replacement := range replacements {
+			if replacement == "" {
+				continue
+			}
+			if err := config.ReplaceFeedEntry(entries[i], replacement); err != nil {
+				problems = append(problems, err)
+				continue
+			}
+			migrated += 1
+		}
+		s.m.Unlock()
+
+		result := splicer.NewSplicer(config.Parsed.Feeds[input])
+
+		s.m.Lock()
+		defer s.m.Unlock()
+		s.switchTo(result)
+		s.mode = problem
+		if len(problems) != 0 {
+			s.buffer = "Failed to migrate feed: " + errors.Join(problems...).Error()
+		} else if migrated == 1 {
+			s.buffer = "Migrated 1 moved account in " + input
+		} else {
+			s.buffer = fmt.Sprintf("Migrated %d moved accounts in %s", migrated, input)
+		}
+		s.output(s.view())
+		s.mode = normal
+		s.buffer = ""
+	}()
+}
+
 func NewState(width int, height int, output func(string)) *State {
 	s := &State{
 		h:      history.History[*Page]{},
 		width:  width,
 		height: height,
-		output: output,
+		/* Some image protocols need work done once the frame is laid out */
+		output: func(frame string) { output(graphics.Finalize(frame)) },
 		m:      &sync.Mutex{},
 		mode:   loading,
 	}
+	graphics.OnLoad(func() {
+		s.m.Lock()
+		s.output(s.view())
+		s.m.Unlock()
+	})
 	return s
 }
 
 func (s *State) Subcommand(name, argument string) error {
 	s.m.Lock()
-	if name == "feed" {
+	if name == "seek" {
+		return errors.New("seek can only be used from within servitor")
+	}
+	if name == "feed" || name == "migrate" {
 		if _, present := config.Parsed.Feeds[argument]; !present {
 			return errors.New("failed to open feed: " + argument + " is not a known feed")
 		}
@@ -477,6 +966,10 @@ func (s *State) subcommand(name, argument string) error {
 		s.openUserInput(argument)
 	case "feed":
 		s.openFeed(argument)
+	case "migrate":
+		s.migrateFeed(argument)
+	case "seek":
+		return s.seek(argument)
 	default:
 		return fmt.Errorf("unrecognized subcommand: %s", name)
 	}